@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/cup"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+// runCup prints the cup standings over the races listed in a cup file
+func runCup(args []string) {
+	flags := flag.NewFlagSet("cup", flag.ExitOnError)
+	format := flags.String("format", "text", "output format: text or json")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: biathlon-tracker cup [-format text|json] <cup_file>")
+		os.Exit(1)
+	}
+
+	config, err := loadCup(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Race files are relative to the cup file
+	dir := filepath.Dir(flags.Arg(0))
+	path := func(name string) string {
+		if name == "" || filepath.IsAbs(name) {
+			return name
+		}
+		return filepath.Join(dir, name)
+	}
+
+	races := make([]*service.ResultsExport, 0, len(config.Races))
+	for i, race := range config.Races {
+		var results *service.ResultsExport
+		if race.Results != "" {
+			results, err = loadResults(path(race.Results))
+		} else {
+			var competition *service.CompetitionService
+			competition, err = runCompetition(path(race.Config), path(race.Events), path(race.Athletes), "")
+			if err == nil {
+				results = competition.ExportResults()
+			}
+		}
+		if err != nil {
+			fmt.Printf("Race %d: %v\n", i+1, err)
+			os.Exit(1)
+		}
+		races = append(races, results)
+	}
+
+	standings, err := cup.Compute(config, races)
+	if err != nil {
+		fmt.Printf("Error computing standings: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch *format {
+	case "text":
+		fmt.Print(standings.String())
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(standings); err != nil {
+			fmt.Printf("Error writing standings: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown output format: %s\n", *format)
+		os.Exit(1)
+	}
+}
+
+func loadCup(path string) (*cup.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading cup file: %v", err)
+	}
+
+	var config cup.Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing cup file: %v", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid cup: %v", err)
+	}
+	return &config, nil
+}
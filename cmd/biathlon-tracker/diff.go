@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+// runDiff compares the results of two runs. Like diff(1) it exits with 1 when the
+// results differ and with 2 on errors.
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	athletesPath := flags.String("athletes", "", "path to the athletes file (JSON)")
+	flags.Parse(args)
+
+	var beforeConfig, beforeEvents, afterConfig, afterEvents string
+	switch flags.NArg() {
+	case 3:
+		beforeConfig, beforeEvents = flags.Arg(0), flags.Arg(1)
+		afterConfig, afterEvents = flags.Arg(0), flags.Arg(2)
+	case 4:
+		beforeConfig, beforeEvents = flags.Arg(0), flags.Arg(1)
+		afterConfig, afterEvents = flags.Arg(2), flags.Arg(3)
+	default:
+		fmt.Println("Usage: biathlon-tracker diff [-athletes athletes_file] <config_file> <events_file> <other_events_file>")
+		fmt.Println("       biathlon-tracker diff [-athletes athletes_file] <config_file> <events_file> <other_config_file> <other_events_file>")
+		os.Exit(2)
+	}
+
+	before, err := runCompetition(beforeConfig, beforeEvents, *athletesPath, "")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+	after, err := runCompetition(afterConfig, afterEvents, *athletesPath, "")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
+	changes := service.DiffResults(before, after)
+	fmt.Print(service.FormatDiff(changes))
+	if len(changes) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+	"github.com/numero_quadro/biathlon-tracker/internal/store"
+)
+
+// runHistory lists the competitions of a database or shows the stored results of one
+func runHistory(args []string) {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	format := flags.String("format", "text", "output format: text or json")
+	flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		fmt.Println("Usage: biathlon-tracker history [-format text|json] <db_file> [<competition_id>]")
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Printf("Unknown output format: %s\n", *format)
+		os.Exit(1)
+	}
+
+	db, err := store.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if flags.NArg() == 1 {
+		competitions, err := db.Competitions()
+		if err != nil {
+			fmt.Printf("Error reading competitions: %v\n", err)
+			os.Exit(1)
+		}
+		if *format == "json" {
+			printJSON(competitions)
+			return
+		}
+		for _, c := range competitions {
+			fmt.Printf("%d\t%s\t%s\t%d events\tstored %s\n", c.ID, c.Name, c.Date, c.Events, c.Created.Format("2006-01-02 15:04"))
+		}
+		return
+	}
+
+	id, err := strconv.ParseInt(flags.Arg(1), 10, 64)
+	if err != nil {
+		fmt.Printf("Invalid competition ID: %s\n", flags.Arg(1))
+		os.Exit(1)
+	}
+	results, err := db.Results(id)
+	if err != nil {
+		fmt.Printf("Error reading results: %v\n", err)
+		os.Exit(1)
+	}
+	if *format == "json" {
+		// Same shape as the results export of a single race
+		export := make([]service.ExportedResult, 0, len(results))
+		for _, r := range results {
+			exported := service.ExportedResult{
+				Rank: r.Rank, CategoryRank: r.CategoryRank, CompetitorID: r.CompetitorID, AthleteID: r.AthleteID,
+				Bib: r.Bib, Name: r.Name, Category: r.Category, Status: r.Status,
+				Hits: r.Hits, Shots: r.Shots, Comment: r.Comment,
+			}
+			if r.Rank > 0 {
+				exported.Time = service.FormatDuration(r.Time)
+				exported.Behind = "+" + service.FormatDuration(r.Behind)
+			}
+			export = append(export, exported)
+		}
+		printJSON(export)
+		return
+	}
+	for _, r := range results {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("Competitor %d", r.CompetitorID)
+		}
+		if r.Rank == 0 {
+			fmt.Printf("  -\t%s\t%s\t%s\n", name, r.Status, r.Comment)
+			continue
+		}
+		fmt.Printf("%3d\t%s\t%s\t+%s\t%d/%d\n", r.Rank, name,
+			service.FormatDuration(r.Time), service.FormatDuration(r.Behind), r.Hits, r.Shots)
+	}
+}
+
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Printf("Error writing JSON: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newRecorder opens the database and stores the competition's events and results
+// in it as they are processed
+func newRecorder(path string, competition *service.CompetitionService, onError func(err error)) (*store.Store, *store.Recorder, error) {
+	db, err := store.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening database: %v", err)
+	}
+	recorder, err := db.NewRecorder(competition.Config(), competition.Athletes())
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	recorder.OnError = onError
+	competition.AddHandler(recorder)
+	return db, recorder, nil
+}
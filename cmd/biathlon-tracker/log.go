@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+// runLog prints the event log entries matching the filter flags
+func runLog(args []string) {
+	flags := flag.NewFlagSet("log", flag.ExitOnError)
+	athletesPath := flags.String("athletes", "", "path to the athletes file (JSON)")
+	competitors := flags.String("competitor", "", "comma-separated competitor IDs")
+	eventIDs := flags.String("event", "", "comma-separated event IDs, 33 for finishes")
+	lines := flags.String("line", "", "comma-separated firing lines")
+	from := flags.String("from", "", "first time of day to include, hh:mm[:ss[.sss]]")
+	to := flags.String("to", "", "last time of day to include, hh:mm[:ss[.sss]]")
+	warnings := flags.Bool("warnings", false, "only show warnings")
+	format := flags.String("format", "text", "output format: text or jsonl")
+	language := languageFlag(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		fmt.Println("Usage: biathlon-tracker log [-athletes athletes_file] [-competitor ids] [-event ids] [-line lines] [-from time] [-to time] [-warnings] [-lang language] [-format text|jsonl] <config_file> <events_file>")
+		os.Exit(1)
+	}
+
+	var filter service.LogFilter
+	var err error
+	if filter.Competitors, err = parseIntList(*competitors); err != nil {
+		fmt.Printf("Invalid competitor IDs: %v\n", err)
+		os.Exit(1)
+	}
+	if filter.EventIDs, err = parseIntList(*eventIDs); err != nil {
+		fmt.Printf("Invalid event IDs: %v\n", err)
+		os.Exit(1)
+	}
+	if filter.FiringLines, err = parseIntList(*lines); err != nil {
+		fmt.Printf("Invalid firing lines: %v\n", err)
+		os.Exit(1)
+	}
+	if filter.From, err = parseOptionalClock(*from); err != nil {
+		fmt.Printf("Invalid -from: %v\n", err)
+		os.Exit(1)
+	}
+	if filter.To, err = parseOptionalClock(*to); err != nil {
+		fmt.Printf("Invalid -to: %v\n", err)
+		os.Exit(1)
+	}
+	if *warnings {
+		filter.Kind = service.LogWarning
+	}
+
+	competition, err := runCompetition(flags.Arg(0), flags.Arg(1), *athletesPath, *language)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	entries := competition.GetFilteredLog(filter)
+
+	switch *format {
+	case "text":
+		for _, entry := range entries {
+			fmt.Println(entry.Message)
+		}
+	case "jsonl":
+		encoder := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				fmt.Printf("Error writing JSON: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	default:
+		fmt.Printf("Unknown output format: %s\n", *format)
+		os.Exit(1)
+	}
+}
+
+// parseIntList parses a comma-separated list of integers; an empty string gives nil
+func parseIntList(value string) ([]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	values := make([]int, 0)
+	for _, part := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+func parseOptionalClock(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return service.ParseClock(value)
+}
@@ -3,47 +3,215 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
-	"regexp"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/i18n"
+	"github.com/numero_quadro/biathlon-tracker/internal/notify"
+	"github.com/numero_quadro/biathlon-tracker/internal/report"
 	"github.com/numero_quadro/biathlon-tracker/internal/service"
+	"github.com/numero_quadro/biathlon-tracker/internal/store"
 )
 
+// commands maps subcommand names to their entry points; without a known
+// subcommand the arguments are handled by runReport
+var commands = map[string]func(args []string){
+	"watch":     runWatch,
+	"simulate":  runSimulate,
+	"replay":    runReplay,
+	"diff":      runDiff,
+	"validate":  runValidate,
+	"timeline":  runTimeline,
+	"positions": runPositions,
+	"pursuit":   runPursuit,
+	"cup":       runCup,
+	"races":     runRaces,
+	"serve":     runServe,
+	"device":    runDevice,
+	"history":   runHistory,
+	"log":       runLog,
+}
+
+// languageFlag registers the -lang flag of the commands printing the event log or results
+func languageFlag(flags *flag.FlagSet) *string {
+	return flags.String("lang", i18n.DefaultLanguage,
+		"language of the event log and result headings: "+strings.Join(i18n.Languages(), ", "))
+}
+
 func main() {
-	if len(os.Args) != 3 {
-		fmt.Println("Usage: biathlon-tracker <config_file> <events_file>")
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
+	runReport(os.Args[1:])
+}
+
+func runReport(args []string) {
+	flags := flag.NewFlagSet("biathlon-tracker", flag.ExitOnError)
+	athletesPath := flags.String("athletes", "", "path to the athletes file (JSON)")
+	format := flags.String("format", "text", "output format: text, html, pdf, json or events")
+	refresh := flags.Int("refresh", 0, "auto-refresh interval of the HTML page in seconds (0 disables it)")
+	outputPath := flags.String("o", "", "write the output to a file instead of stdout")
+	breakdown := flags.Bool("breakdown", false, "add the ski, range and penalty time breakdown to the text output")
+	dbPath := flags.String("db", "", "store the config, events and results in this SQLite file")
+	outgoingPath := flags.String("outgoing", "", "also write the outgoing events to this file in the events file format")
+	language := languageFlag(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		fmt.Println("Usage: biathlon-tracker [-athletes athletes_file] [-format text|html|pdf|json|events] [-refresh seconds] [-breakdown] [-db db_file] [-outgoing events_file] [-lang language] [-o output_file] <config_file> <events_file>")
+		fmt.Println("       biathlon-tracker watch [flags] <config_file> <events_file|->")
+		fmt.Println("       biathlon-tracker simulate [flags] <config_file>")
+		fmt.Println("       biathlon-tracker replay [flags] <config_file> <events_file>")
+		fmt.Println("       biathlon-tracker diff [flags] <config_file> <events_file> [<config_file>] <events_file>")
+		fmt.Println("       biathlon-tracker validate [flags] <config_file> <events_file>")
+		fmt.Println("       biathlon-tracker log [flags] <config_file> <events_file>")
+		fmt.Println("       biathlon-tracker timeline [flags] <config_file> <events_file> <competitor_id>")
+		fmt.Println("       biathlon-tracker positions [flags] <config_file> <events_file>")
+		fmt.Println("       biathlon-tracker pursuit [flags] <pursuit_config> <results.json | config_file events_file>")
+		fmt.Println("       biathlon-tracker cup [flags] <cup_file>")
+		fmt.Println("       biathlon-tracker races [flags] <races_file> [<events_file|->]")
+		fmt.Println("       biathlon-tracker serve [flags] <config_file>")
+		fmt.Println("       biathlon-tracker device [flags] <address> <events_file|->")
+		fmt.Println("       biathlon-tracker history [flags] <db_file> [<competition_id>]")
 		os.Exit(1)
 	}
 
-	config, err := loadConfig(os.Args[1])
+	competition, err := runCompetition(flags.Arg(0), flags.Arg(1), *athletesPath, *language)
 	if err != nil {
-		fmt.Printf("Error loading config: %v\n", err)
+		fmt.Println(err)
 		os.Exit(1)
 	}
+	config := competition.Config()
+
+	if *dbPath != "" {
+		db, err := store.Open(*dbPath)
+		if err != nil {
+			fmt.Printf("Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		id, err := db.SaveCompetition(competition)
+		db.Close()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		// Keep stdout for the report itself
+		fmt.Fprintf(os.Stderr, "Stored as competition %d in %s\n", id, *dbPath)
+	}
+
+	if *outgoingPath != "" {
+		if err := writeOutgoingEvents(*outgoingPath, competition); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
+	out := os.Stdout
+	if *outputPath != "" {
+		out, err = os.Create(*outputPath)
+		if err != nil {
+			fmt.Printf("Error creating output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+
+	text := competition.Printer()
+	switch *format {
+	case "text":
+		// Print event log
+		fmt.Fprintf(out, "%s\n", text.Sprintf("header.log"))
+		fmt.Fprintln(out, competition.GetEventLog())
+		fmt.Fprintf(out, "\n%s\n", text.Sprintf("header.results"))
+		fmt.Fprint(out, competition.GetFinalReport())
+		if *athletesPath != "" || len(config.Categories) > 0 {
+			fmt.Fprintf(out, "\n%s\n", text.Sprintf("header.categories"))
+			fmt.Fprint(out, competition.GetCategoryReport())
+		}
+		if *breakdown {
+			fmt.Fprintf(out, "\n%s\n", text.Sprintf("header.breakdown"))
+			fmt.Fprint(out, competition.GetBreakdownReport())
+		}
+	case "html":
+		page := report.NewResultsPage(competition, time.Now())
+		if err := report.WriteHTML(out, page, report.HTMLOptions{Refresh: *refresh}); err != nil {
+			fmt.Printf("Error writing HTML: %v\n", err)
+			os.Exit(1)
+		}
+	case "pdf":
+		page := report.NewResultsPage(competition, time.Now())
+		if err := report.WritePDF(out, page, report.PDFOptions{}); err != nil {
+			fmt.Printf("Error writing PDF: %v\n", err)
+			os.Exit(1)
+		}
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(competition.ExportResults()); err != nil {
+			fmt.Printf("Error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+	case "events":
+		if err := competition.WriteOutgoingEvents(out); err != nil {
+			fmt.Printf("Error writing events: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown output format: %s\n", *format)
+		os.Exit(1)
+	}
+}
+
+// newCompetition creates a competition from a config file and an optional athletes
+// file. An empty language keeps the default catalog.
+func newCompetition(configPath, athletesPath, language string) (*service.CompetitionService, error) {
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %v", err)
+	}
 
 	competition := service.NewCompetitionService(config)
-	events, err := loadEvents(os.Args[2])
+	if language != "" {
+		if err := competition.SetLanguage(language); err != nil {
+			return nil, err
+		}
+	}
+	if athletesPath != "" {
+		athletes, err := loadAthletes(athletesPath)
+		if err != nil {
+			return nil, fmt.Errorf("error loading athletes: %v", err)
+		}
+		competition.SetAthletes(athletes)
+	}
+	return competition, nil
+}
+
+// runCompetition processes a whole events file and returns the resulting competition
+func runCompetition(configPath, eventsPath, athletesPath, language string) (*service.CompetitionService, error) {
+	competition, err := newCompetition(configPath, athletesPath, language)
 	if err != nil {
-		fmt.Printf("Error loading events: %v\n", err)
-		os.Exit(1)
+		return nil, err
+	}
+
+	events, err := loadEvents(eventsPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading events: %v", err)
 	}
 
 	for _, event := range events {
 		if err := competition.ProcessEvent(event); err != nil {
-			fmt.Printf("Error processing event: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("error processing event: %v", err)
 		}
 	}
-
-	// Print event log
-	fmt.Print("Output log\n")
-	fmt.Println(competition.GetEventLog())
-	fmt.Print("\nResulting table\n")
-	fmt.Print(competition.GetFinalReport())
+	return competition, nil
 }
 
 func loadConfig(path string) (*domain.Config, error) {
@@ -64,6 +232,69 @@ func loadConfig(path string) (*domain.Config, error) {
 	return &config, nil
 }
 
+func loadAthletes(path string) (domain.AthleteRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading athletes file: %v", err)
+	}
+
+	var athletes []*domain.Athlete
+	if err := json.Unmarshal(data, &athletes); err != nil {
+		return nil, fmt.Errorf("error parsing athletes file: %v", err)
+	}
+
+	registry, err := domain.NewAthleteRegistry(athletes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid athletes: %v", err)
+	}
+
+	return registry, nil
+}
+
+// newNotifier reads the webhooks file and starts a notifier
+func newNotifier(path string) (*notify.Notifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading webhooks file: %v", err)
+	}
+
+	opts := notify.DefaultOptions()
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return nil, fmt.Errorf("error parsing webhooks file: %v", err)
+	}
+
+	notifier, err := notify.New(opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhooks: %v", err)
+	}
+	return notifier, nil
+}
+
+// writeOutgoingEvents writes the outgoing events of a processed competition to a file
+func writeOutgoingEvents(path string, competition *service.CompetitionService) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating outgoing events file: %v", err)
+	}
+	if err := competition.WriteOutgoingEvents(file); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing outgoing events: %v", err)
+	}
+	return file.Close()
+}
+
+// newOutgoingFeed creates the file and writes every outgoing event of the competition
+// to it as soon as it is produced
+func newOutgoingFeed(path string, competition *service.CompetitionService) (*os.File, *service.OutgoingFeed, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating outgoing events file: %v", err)
+	}
+	feed := service.NewOutgoingFeed(file)
+	competition.AddHandler(feed)
+	return file, feed, nil
+}
+
 func loadEvents(path string) ([]*domain.Event, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -73,36 +304,15 @@ func loadEvents(path string) ([]*domain.Event, error) {
 
 	var events []*domain.Event
 	scanner := bufio.NewScanner(file)
-	eventRegex := regexp.MustCompile(`\[(\d{2}:\d{2}:\d{2}\.\d{3})\] (\d+) (\d+)(?: (.+))?`)
-
 	for scanner.Scan() {
-		line := scanner.Text()
-		matches := eventRegex.FindStringSubmatch(line)
-		if len(matches) < 4 {
+		event, err := domain.ParseEvent(scanner.Text())
+		if errors.Is(err, domain.ErrInvalidEventFormat) {
 			continue
 		}
-
-		time, err := time.Parse("15:04:05.000", matches[1])
-		if err != nil {
-			return nil, fmt.Errorf("error parsing time: %v", err)
-		}
-
-		eventID, err := strconv.Atoi(matches[2])
-		if err != nil {
-			return nil, fmt.Errorf("error parsing event ID: %v", err)
-		}
-
-		competitorID, err := strconv.Atoi(matches[3])
 		if err != nil {
-			return nil, fmt.Errorf("error parsing competitor ID: %v", err)
+			return nil, err
 		}
-
-		extraParams := ""
-		if len(matches) > 4 {
-			extraParams = matches[4]
-		}
-
-		events = append(events, domain.NewEvent(time, domain.EventTypeIncoming, eventID, competitorID, extraParams))
+		events = append(events, event)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runPositions prints the lap-by-lap positions of the field
+func runPositions(args []string) {
+	flags := flag.NewFlagSet("positions", flag.ExitOnError)
+	athletesPath := flags.String("athletes", "", "path to the athletes file (JSON)")
+	format := flags.String("format", "text", "output format: text, json or csv")
+	top := flags.Int("top", 3, "number of gainers and losers in the text summary")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		fmt.Println("Usage: biathlon-tracker positions [-athletes athletes_file] [-format text|json|csv] [-top n] <config_file> <events_file>")
+		os.Exit(1)
+	}
+
+	competition, err := runCompetition(flags.Arg(0), flags.Arg(1), *athletesPath, "")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	chart := competition.GetPositionChart()
+
+	switch *format {
+	case "text":
+		fmt.Print(chart.Summary(*top))
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(chart)
+	case "csv":
+		err = chart.WriteCSV(os.Stdout)
+	default:
+		fmt.Printf("Unknown output format: %s\n", *format)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Error writing positions: %v\n", err)
+		os.Exit(1)
+	}
+}
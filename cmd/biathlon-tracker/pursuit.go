@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/pursuit"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+// runPursuit generates the start list of a pursuit from the results of a previous race,
+// read either from a JSON results export or from its config and events files
+func runPursuit(args []string) {
+	flags := flag.NewFlagSet("pursuit", flag.ExitOnError)
+	athletesPath := flags.String("athletes", "", "path to the athletes file (JSON)")
+	top := flags.Int("top", 60, "number of qualified competitors, 0 takes all finishers")
+	category := flags.String("category", "", "only start competitors of this category")
+	waveCutoff := flags.Duration("wave-cutoff", 0, "competitors further behind start together in a wave, 0 disables it")
+	round := flags.Duration("round", 0, "truncate the gaps to this resolution, e.g. 1s")
+	drawBefore := flags.Duration("draw-before", 30*time.Minute, "time of the registration and start draw events before the start")
+	eventsPath := flags.String("events", "", "write the registration and start draw events to this file")
+	configOut := flags.String("config-out", "", "write the pursuit config with individual starts to this file")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 && flags.NArg() != 3 {
+		fmt.Println("Usage: biathlon-tracker pursuit [-athletes athletes_file] [-top n] [-category name] [-wave-cutoff duration] [-round duration] [-draw-before duration] [-events events_file] [-config-out config_file] <pursuit_config> <results.json | config_file events_file>")
+		os.Exit(1)
+	}
+
+	template, err := loadConfig(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error loading pursuit config: %v\n", err)
+		os.Exit(1)
+	}
+	start, err := time.Parse("15:04:05.000", template.Start)
+	if err != nil {
+		fmt.Printf("Error parsing pursuit start: %v\n", err)
+		os.Exit(1)
+	}
+
+	var results *service.ResultsExport
+	if flags.NArg() == 2 {
+		results, err = loadResults(flags.Arg(1))
+	} else {
+		var competition *service.CompetitionService
+		competition, err = runCompetition(flags.Arg(1), flags.Arg(2), *athletesPath, "")
+		if err == nil {
+			results = competition.ExportResults()
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	list, err := pursuit.Generate(results, pursuit.Options{
+		Top:        *top,
+		Category:   *category,
+		Start:      start,
+		WaveCutoff: *waveCutoff,
+		Round:      *round,
+	})
+	if err != nil {
+		fmt.Printf("Error generating start list: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *eventsPath != "" {
+		var b strings.Builder
+		for _, event := range list.Events(start.Add(-*drawBefore)) {
+			b.WriteString(event.String() + "\n")
+		}
+		if err := os.WriteFile(*eventsPath, []byte(b.String()), 0o644); err != nil {
+			fmt.Printf("Error writing events file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *configOut != "" {
+		data, err := json.MarshalIndent(list.Config(template), "", "  ")
+		if err == nil {
+			err = os.WriteFile(*configOut, append(data, '\n'), 0o644)
+		}
+		if err != nil {
+			fmt.Printf("Error writing config file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if results.Name != "" {
+		fmt.Printf("Pursuit start list after %s\n", results.Name)
+	} else {
+		fmt.Println("Pursuit start list")
+	}
+	fmt.Print(list.String())
+}
+
+// loadResults reads a JSON results export written with -format json
+func loadResults(path string) (*service.ResultsExport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading results file: %v", err)
+	}
+
+	var results service.ResultsExport
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("error parsing results file: %v", err)
+	}
+	return &results, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+// raceFile is an entry of the races file; paths are relative to the races file
+type raceFile struct {
+	ID       string `json:"id"`
+	Config   string `json:"config"`
+	Athletes string `json:"athletes"`
+	Events   string `json:"events"`
+}
+
+// runRaces runs several competitions side by side. Each race may have its own events
+// file; a combined stream with lines prefixed by the race ID is routed to the races.
+func runRaces(args []string) {
+	flags := flag.NewFlagSet("races", flag.ExitOnError)
+	race := flags.String("race", "", "only print the results of this race")
+	format := flags.String("format", "text", "output format: text or json")
+	language := languageFlag(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 && flags.NArg() != 2 {
+		fmt.Println("Usage: biathlon-tracker races [-race id] [-format text|json] [-lang language] <races_file> [<events_file|->]")
+		os.Exit(1)
+	}
+
+	registry, err := newRegistry(flags.Arg(0), *language)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if path := flags.Arg(1); path != "" {
+		var source io.Reader = os.Stdin
+		if path != "-" {
+			file, err := os.Open(path)
+			if err != nil {
+				fmt.Printf("Error opening events file: %v\n", err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			source = file
+		}
+		// Events without a race ID are reported on stderr, the results stay on stdout
+		scanner := bufio.NewScanner(source)
+		unrouted := 0
+		for scanner.Scan() {
+			err := registry.ProcessLine(scanner.Text())
+			if errors.Is(err, domain.ErrInvalidEventFormat) {
+				continue
+			}
+			if errors.Is(err, service.ErrMissingCompetitionID) {
+				fmt.Fprintf(os.Stderr, "Skipping event without a race ID: %s\n", strings.TrimSpace(scanner.Text()))
+				unrouted++
+				continue
+			}
+			if err != nil {
+				fmt.Printf("Error processing event: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Printf("Error reading events file: %v\n", err)
+			os.Exit(1)
+		}
+		if unrouted > 0 {
+			fmt.Fprintf(os.Stderr, "Skipped %d events without a race ID\n", unrouted)
+		}
+	}
+
+	ids := registry.IDs()
+	if *race != "" {
+		if _, err := registry.Get(*race); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		ids = []string{*race}
+	}
+
+	switch *format {
+	case "text":
+		for i, id := range ids {
+			if i > 0 {
+				fmt.Println()
+			}
+			competition, _ := registry.Get(id)
+			fmt.Printf("Resulting table of %s\n", id)
+			fmt.Print(competition.GetFinalReport())
+		}
+	case "json":
+		exports := registry.ExportResults()
+		var output interface{} = exports
+		if *race != "" {
+			output = exports[*race]
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			fmt.Printf("Error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown output format: %s\n", *format)
+		os.Exit(1)
+	}
+}
+
+// newRegistry creates the competitions listed in a races file and processes their own
+// events files
+func newRegistry(path, language string) (*service.Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading races file: %v", err)
+	}
+
+	var races []raceFile
+	if err := json.Unmarshal(data, &races); err != nil {
+		return nil, fmt.Errorf("error parsing races file: %v", err)
+	}
+	if len(races) == 0 {
+		return nil, fmt.Errorf("no races in %s", path)
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(name string) string {
+		if name == "" || filepath.IsAbs(name) {
+			return name
+		}
+		return filepath.Join(dir, name)
+	}
+
+	registry := service.NewRegistry()
+	for _, race := range races {
+		competition, err := newCompetition(resolve(race.Config), resolve(race.Athletes), language)
+		if err != nil {
+			return nil, fmt.Errorf("race %s: %v", race.ID, err)
+		}
+		if err := registry.Add(race.ID, competition); err != nil {
+			return nil, err
+		}
+		if race.Events == "" {
+			continue
+		}
+		events, err := loadEvents(resolve(race.Events))
+		if err != nil {
+			return nil, fmt.Errorf("race %s: error loading events: %v", race.ID, err)
+		}
+		for _, event := range events {
+			if err := registry.ProcessEvent(race.ID, event); err != nil {
+				return nil, fmt.Errorf("error processing event: %v", err)
+			}
+		}
+	}
+	return registry, nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/dashboard"
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/notify"
+	"github.com/numero_quadro/biathlon-tracker/internal/replay"
+)
+
+// runReplay plays a recorded events file into the competition at a chosen speed.
+// Playback is controlled with commands typed on stdin followed by Enter.
+func runReplay(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	athletesPath := flags.String("athletes", "", "path to the athletes file (JSON)")
+	speed := flags.Float64("speed", 1, "playback speed multiplier, 0 replays instantly")
+	from := flags.String("from", "", "start the replay at this race time (hh:mm:ss.sss)")
+	showDashboard := flags.Bool("dashboard", false, "show the live dashboard instead of the event log")
+	noColor := flags.Bool("no-color", false, "disable dashboard colors")
+	webhooksPath := flags.String("webhooks", "", "path to the webhooks file (JSON)")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		fmt.Println("Usage: biathlon-tracker replay [-athletes athletes_file] [-speed factor] [-from hh:mm:ss.sss] [-dashboard] [-webhooks webhooks_file] <config_file> <events_file>")
+		fmt.Println("Controls: p (pause/resume), s hh:mm:ss.sss (seek), +N / -N (seek by N seconds), x factor (speed)")
+		os.Exit(1)
+	}
+
+	competition, err := newCompetition(flags.Arg(0), *athletesPath, "")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	events, err := loadEvents(flags.Arg(1))
+	if err != nil {
+		fmt.Printf("Error loading events: %v\n", err)
+		os.Exit(1)
+	}
+	var notifier *notify.Notifier
+	if *webhooksPath != "" {
+		notifier, err = newNotifier(*webhooksPath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer notifier.Close()
+		competition.AddHandler(notifier)
+	}
+
+	// The player, the dashboard and the log printer share the competition
+	var mu sync.Mutex
+	printed := 0
+	sink := func(event *domain.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := competition.ProcessEvent(event); err != nil {
+			return err
+		}
+		if !*showDashboard {
+			var entries []string
+			entries, printed = competition.GetNewLogEntries(printed)
+			for _, entry := range entries {
+				fmt.Println(entry)
+			}
+		}
+		return nil
+	}
+	reset := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		competition.Reset()
+		if notifier != nil {
+			notifier.Reset()
+		}
+		if !*showDashboard {
+			fmt.Println("-- rewind --")
+		}
+	}
+
+	player, err := replay.NewPlayer(events, sink, reset, *speed)
+	if err != nil {
+		fmt.Printf("Error creating player: %v\n", err)
+		os.Exit(1)
+	}
+	if *from != "" {
+		at, err := time.Parse("15:04:05.000", *from)
+		if err != nil {
+			fmt.Printf("Error parsing start time: %v\n", err)
+			os.Exit(1)
+		}
+		// The events before the start were notified when they happened
+		if notifier != nil {
+			notifier.Skip(at)
+		}
+		player.Seek(at)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	go readControls(player)
+
+	done := make(chan error, 1)
+	go func() { done <- player.Run(ctx) }()
+
+	if !*showDashboard {
+		if err := <-done; err != nil && err != context.Canceled {
+			fmt.Printf("Error replaying events: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print("\nResulting table\n")
+		fmt.Print(competition.GetFinalReport())
+		return
+	}
+
+	opts := dashboard.DefaultOptions()
+	opts.Width = envInt("COLUMNS", opts.Width)
+	opts.Height = envInt("LINES", opts.Height)
+	opts.Color = !*noColor
+
+	fmt.Print(dashboard.SessionStart)
+	defer fmt.Print(dashboard.SessionEnd)
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	var runErr error
+	for {
+		select {
+		case runErr = <-done:
+			done = nil
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		mu.Lock()
+		dashboard.Render(os.Stdout, competition, opts)
+		mu.Unlock()
+
+		status := player.Status()
+		state := fmt.Sprintf("%gx", status.Speed)
+		if status.Paused {
+			state = "paused"
+		}
+		if done == nil {
+			state = "finished"
+		}
+		fmt.Printf("\r\nReplay %s  %s  %d/%d events", state, status.Clock.Format("15:04:05.000"), status.Position, status.Total)
+		if runErr != nil && runErr != context.Canceled {
+			fmt.Printf("  error: %v", runErr)
+		}
+	}
+}
+
+// readControls applies the playback commands typed on stdin
+func readControls(player *replay.Player) {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch command := fields[0]; {
+		case command == "p":
+			player.TogglePause()
+		case command == "s" && len(fields) == 2:
+			if at, err := time.Parse("15:04:05.000", fields[1]); err == nil {
+				player.Seek(at)
+			}
+		case command == "x" && len(fields) == 2:
+			if factor, err := strconv.ParseFloat(fields[1], 64); err == nil {
+				player.SetSpeed(factor)
+			}
+		case strings.HasPrefix(command, "+") || strings.HasPrefix(command, "-"):
+			if seconds, err := strconv.ParseFloat(command, 64); err == nil {
+				player.SeekBy(time.Duration(seconds * float64(time.Second)))
+			}
+		}
+	}
+}
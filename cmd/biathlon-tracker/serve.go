@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/ingest"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+	"github.com/numero_quadro/biathlon-tracker/internal/store"
+)
+
+// runServe receives events from timing devices over the network and prints the
+// event log as they are applied; the results are printed on interrupt
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	athletesPath := flags.String("athletes", "", "path to the athletes file (JSON)")
+	ingestPath := flags.String("ingest", "", "path to the ingest options file (JSON) with the vendor mapping")
+	tcpAddr := flags.String("tcp", "", "TCP listen address, e.g. :7000")
+	udpAddr := flags.String("udp", "", "UDP listen address, e.g. :7000")
+	webhooksPath := flags.String("webhooks", "", "path to the webhooks file (JSON)")
+	reorder := flags.Duration("reorder", 0, "hold events back this long to apply them in time order")
+	dbPath := flags.String("db", "", "store the config, events and results in this SQLite file")
+	outgoingPath := flags.String("outgoing", "", "write each outgoing event to this file as it is produced")
+	racesPath := flags.String("races", "", "serve the races of this races file (JSON) instead of a single config")
+	language := languageFlag(flags)
+	flags.Parse(args)
+
+	if *racesPath != "" && flags.NArg() == 0 {
+		if *athletesPath != "" || *webhooksPath != "" || *dbPath != "" || *outgoingPath != "" {
+			fmt.Println("-races takes the athletes from the races file and does not support -webhooks, -db or -outgoing")
+			os.Exit(1)
+		}
+		opts, err := ingestOptions(*ingestPath, *tcpAddr, *udpAddr)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		serveRaces(*racesPath, *language, opts, *reorder)
+		return
+	}
+	if *racesPath != "" || flags.NArg() != 1 {
+		fmt.Println("Usage: biathlon-tracker serve [-athletes athletes_file] [-ingest ingest_file] [-tcp address] [-udp address] [-webhooks webhooks_file] [-reorder duration] [-db db_file] [-outgoing events_file] [-lang language] <config_file>")
+		fmt.Println("       biathlon-tracker serve -races races_file [-ingest ingest_file] [-tcp address] [-udp address] [-reorder duration] [-lang language]")
+		os.Exit(1)
+	}
+
+	competition, err := newCompetition(flags.Arg(0), *athletesPath, *language)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *webhooksPath != "" {
+		notifier, err := newNotifier(*webhooksPath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer notifier.Close()
+		competition.AddHandler(notifier)
+	}
+	var recorder *store.Recorder
+	if *dbPath != "" {
+		var db *store.Store
+		db, recorder, err = newRecorder(*dbPath, competition, func(err error) {
+			fmt.Printf("Error storing event: %v\n", err)
+		})
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer db.Close()
+	}
+	if *outgoingPath != "" {
+		file, feed, err := newOutgoingFeed(*outgoingPath, competition)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		defer func() {
+			if err := feed.Err(); err != nil {
+				fmt.Printf("Error writing outgoing events: %v\n", err)
+			}
+		}()
+	}
+
+	opts, err := ingestOptions(*ingestPath, *tcpAddr, *udpAddr)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	printed := 0
+	printLog := func() {
+		var entries []string
+		entries, printed = competition.GetNewLogEntries(printed)
+		for _, entry := range entries {
+			fmt.Println(entry)
+		}
+	}
+
+	// Without reordering every event is applied right away and its error goes back to
+	// the device. Buffered events are acknowledged on arrival; errors of events applied
+	// later are only printed here.
+	sink := func(event *domain.Event) error {
+		if err := competition.ProcessEvent(event); err != nil {
+			return err
+		}
+		printLog()
+		return nil
+	}
+	buffer := service.NewReorderBuffer(competition, *reorder)
+	if *reorder > 0 {
+		sink = func(event *domain.Event) error {
+			if err := buffer.Add(event); err != nil {
+				fmt.Printf("Error processing event: %v\n", err)
+			}
+			printLog()
+			return nil
+		}
+	}
+
+	server, err := ingest.Listen(opts, sink)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	serveUntilInterrupt(server, func() {
+		if err := buffer.Expire(time.Now()); err != nil {
+			fmt.Printf("Error processing event: %v\n", err)
+		}
+		printLog()
+	})
+	if err := buffer.Flush(); err != nil {
+		fmt.Printf("Error processing event: %v\n", err)
+	}
+	printLog()
+	// The results change without a finish while competitors are still racing
+	if recorder != nil {
+		if err := recorder.SaveResults(competition); err != nil {
+			fmt.Printf("Error storing results: %v\n", err)
+		}
+	}
+
+	stats := server.Stats()
+	fmt.Printf("\nReceived %d events: %d duplicates, %d rejected\n", stats.Accepted, stats.Duplicates, stats.Rejected)
+	fmt.Print("\nResulting table\n")
+	fmt.Print(competition.GetFinalReport())
+}
+
+// serveRaces receives the combined stream of the races in a races file, each line
+// prefixed by the race ID, and prints the log entries of every race prefixed by its ID;
+// the results of every race are printed on interrupt
+func serveRaces(racesPath, language string, opts ingest.Options, reorder time.Duration) {
+	registry, err := newRegistry(racesPath, language)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	ids := registry.IDs()
+	buffers := make(map[string]*service.ReorderBuffer, len(ids))
+	printed := make(map[string]int, len(ids))
+	for _, id := range ids {
+		competition, _ := registry.Get(id)
+		buffers[id] = service.NewReorderBuffer(competition, reorder)
+		// The events of the race's own events file are not printed again
+		_, printed[id] = competition.GetNewLogEntries(0)
+	}
+	// printLog is called while the registry holds the race
+	printLog := func(id string, competition *service.CompetitionService) {
+		var entries []string
+		entries, printed[id] = competition.GetNewLogEntries(printed[id])
+		for _, entry := range entries {
+			fmt.Printf("%s %s\n", id, entry)
+		}
+	}
+
+	// As with a single race, errors of buffered events are only printed here
+	sink := func(id string, event *domain.Event) error {
+		return registry.Do(id, func(competition *service.CompetitionService) error {
+			if reorder == 0 {
+				if err := competition.ProcessEvent(event); err != nil {
+					return err
+				}
+			} else if err := buffers[id].Add(event); err != nil {
+				fmt.Printf("Error processing event of %s: %v\n", id, err)
+			}
+			printLog(id, competition)
+			return nil
+		})
+	}
+	release := func(apply func(buffer *service.ReorderBuffer) error) {
+		for _, id := range ids {
+			registry.Do(id, func(competition *service.CompetitionService) error {
+				if err := apply(buffers[id]); err != nil {
+					fmt.Printf("Error processing event of %s: %v\n", id, err)
+				}
+				printLog(id, competition)
+				return nil
+			})
+		}
+	}
+
+	server, err := ingest.ListenRoutes(opts, sink)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	serveUntilInterrupt(server, func() {
+		release(func(buffer *service.ReorderBuffer) error { return buffer.Expire(time.Now()) })
+	})
+	release((*service.ReorderBuffer).Flush)
+
+	stats := server.Stats()
+	fmt.Printf("\nReceived %d events: %d duplicates, %d rejected\n", stats.Accepted, stats.Duplicates, stats.Rejected)
+	for _, id := range ids {
+		competition, _ := registry.Get(id)
+		fmt.Printf("\nResulting table of %s\n", id)
+		fmt.Print(competition.GetFinalReport())
+	}
+}
+
+// ingestOptions reads the ingest options file, if any, and applies the listen addresses
+// given on the command line
+func ingestOptions(path, tcpAddr, udpAddr string) (ingest.Options, error) {
+	opts := ingest.DefaultOptions()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return opts, fmt.Errorf("error reading ingest file: %v", err)
+		}
+		if err := json.Unmarshal(data, &opts); err != nil {
+			return opts, fmt.Errorf("error parsing ingest file: %v", err)
+		}
+	}
+	if tcpAddr != "" {
+		opts.TCP = tcpAddr
+	}
+	if udpAddr != "" {
+		opts.UDP = udpAddr
+	}
+	return opts, nil
+}
+
+// serveUntilInterrupt prints the listen addresses and calls tick periodically while no
+// event is applied, so held events are released after the delay even when no newer
+// event comes. On interrupt it closes the server.
+func serveUntilInterrupt(server *ingest.Server, tick func()) {
+	if addr := server.TCPAddr(); addr != nil {
+		fmt.Printf("Listening on tcp %s\n", addr)
+	}
+	if addr := server.UDPAddr(); addr != nil {
+		fmt.Printf("Listening on udp %s\n", addr)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	expire := time.NewTicker(250 * time.Millisecond)
+	defer expire.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			server.Close()
+			return
+		case <-expire.C:
+			server.Do(tick)
+		}
+	}
+}
+
+// runDevice plays an events file to a serve process like a timing device would,
+// printing the reply to every line
+func runDevice(args []string) {
+	flags := flag.NewFlagSet("device", flag.ExitOnError)
+	udp := flags.Bool("udp", false, "send over UDP instead of TCP")
+	interval := flags.Duration("interval", 0, "pause between lines")
+	timeout := flags.Duration("timeout", time.Second, "wait for a reply before retransmitting")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		fmt.Println("Usage: biathlon-tracker device [-udp] [-interval duration] [-timeout duration] <address> <events_file|->")
+		os.Exit(1)
+	}
+
+	network := "tcp"
+	if *udp {
+		network = "udp"
+	}
+	device, err := ingest.Dial(network, flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error connecting: %v\n", err)
+		os.Exit(1)
+	}
+	defer device.Close()
+	device.Timeout = *timeout
+
+	source := os.Stdin
+	if path := flags.Arg(1); path != "-" {
+		source, err = os.Open(path)
+		if err != nil {
+			fmt.Printf("Error opening events file: %v\n", err)
+			os.Exit(1)
+		}
+		defer source.Close()
+	}
+
+	scanner := bufio.NewScanner(source)
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+		reply, err := device.Send(scanner.Text())
+		if err != nil {
+			fmt.Printf("Error sending %q: %v\n", scanner.Text(), err)
+			os.Exit(1)
+		}
+		fmt.Println(reply)
+		time.Sleep(*interval)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Printf("Error reading events file: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/simulator"
+)
+
+// runSimulate generates a realistic events file for a race config
+func runSimulate(args []string) {
+	defaults := simulator.DefaultOptions()
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	competitors := flags.Int("competitors", defaults.Competitors, "number of competitors")
+	seed := flags.Int64("seed", 0, "random seed (0 picks one from the clock and prints it)")
+	speed := flags.Float64("speed", defaults.Speed, "mean skiing speed in m/s")
+	speedStdDev := flags.Float64("speed-sd", defaults.SpeedStdDev, "standard deviation of the skiing speed")
+	accuracy := flags.Float64("accuracy", defaults.Accuracy, "mean probability of hitting a target")
+	accuracyStdDev := flags.Float64("accuracy-sd", defaults.AccuracyStdDev, "standard deviation of the shooting accuracy")
+	dnsRate := flags.Float64("dns", defaults.DNSRate, "share of competitors who do not start")
+	dnfRate := flags.Float64("dnf", defaults.DNFRate, "share of starters who do not finish")
+	lateRate := flags.Float64("late", defaults.LateStartRate, "share of starters who start late")
+	realtime := flags.Bool("realtime", false, "emit events with the real gaps between them")
+	outputPath := flags.String("o", "", "write the events to a file instead of stdout")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: biathlon-tracker simulate [flags] <config_file>")
+		flags.PrintDefaults()
+		os.Exit(1)
+	}
+
+	config, err := loadConfig(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+		fmt.Fprintf(os.Stderr, "Using seed %d\n", *seed)
+	}
+	events, err := simulator.Generate(config, simulator.Options{
+		Competitors:    *competitors,
+		Seed:           *seed,
+		Speed:          *speed,
+		SpeedStdDev:    *speedStdDev,
+		Accuracy:       *accuracy,
+		AccuracyStdDev: *accuracyStdDev,
+		DNSRate:        *dnsRate,
+		DNFRate:        *dnfRate,
+		LateStartRate:  *lateRate,
+	})
+	if err != nil {
+		fmt.Printf("Error simulating race: %v\n", err)
+		os.Exit(1)
+	}
+
+	out := os.Stdout
+	if *outputPath != "" {
+		out, err = os.Create(*outputPath)
+		if err != nil {
+			fmt.Printf("Error creating output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+
+	writer := bufio.NewWriter(out)
+	defer writer.Flush()
+	for i, event := range events {
+		if *realtime && i > 0 {
+			writer.Flush()
+			time.Sleep(event.Time.Sub(events[i-1].Time))
+		}
+		fmt.Fprintln(writer, event)
+	}
+}
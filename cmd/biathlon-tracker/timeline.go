@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// runTimeline prints the chronological timeline of one competitor
+func runTimeline(args []string) {
+	flags := flag.NewFlagSet("timeline", flag.ExitOnError)
+	athletesPath := flags.String("athletes", "", "path to the athletes file (JSON)")
+	format := flags.String("format", "text", "output format: text or json")
+	flags.Parse(args)
+
+	if flags.NArg() != 3 {
+		fmt.Println("Usage: biathlon-tracker timeline [-athletes athletes_file] [-format text|json] <config_file> <events_file> <competitor_id>")
+		os.Exit(1)
+	}
+	competitorID, err := strconv.Atoi(flags.Arg(2))
+	if err != nil {
+		fmt.Printf("Invalid competitor ID: %s\n", flags.Arg(2))
+		os.Exit(1)
+	}
+
+	competition, err := runCompetition(flags.Arg(0), flags.Arg(1), *athletesPath, "")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	timeline, err := competition.GetTimeline(competitorID)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	switch *format {
+	case "text":
+		fmt.Print(timeline)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(timeline); err != nil {
+			fmt.Printf("Error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown output format: %s\n", *format)
+		os.Exit(1)
+	}
+}
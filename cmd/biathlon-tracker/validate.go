@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/validate"
+)
+
+// runValidate checks a config and events file pair without producing results.
+// It exits with 0 when the files are valid, 1 when errors were found (or warnings
+// with -strict) and 2 when the files cannot be read or the config is invalid.
+func runValidate(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	athletesPath := flags.String("athletes", "", "path to the athletes file (JSON)")
+	strict := flags.Bool("strict", false, "treat warnings as errors")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		fmt.Println("Usage: biathlon-tracker validate [-athletes athletes_file] [-strict] <config_file> <events_file>")
+		os.Exit(2)
+	}
+
+	config, err := loadConfig(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("error loading config: %v\n", err)
+		os.Exit(2)
+	}
+
+	var athletes domain.AthleteRegistry
+	if *athletesPath != "" {
+		athletes, err = loadAthletes(*athletesPath)
+		if err != nil {
+			fmt.Printf("error loading athletes: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	file, err := os.Open(flags.Arg(1))
+	if err != nil {
+		fmt.Printf("error opening events file: %v\n", err)
+		os.Exit(2)
+	}
+	defer file.Close()
+
+	result, err := validate.Check(config, athletes, file)
+	if err != nil {
+		fmt.Printf("error reading events file: %v\n", err)
+		os.Exit(2)
+	}
+
+	fmt.Print(result)
+	if result.Errors() > 0 || (*strict && result.Warnings() > 0) {
+		file.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/dashboard"
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+// runWatch shows a full-screen dashboard while events are appended to the events file
+func runWatch(args []string) {
+	defaults := dashboard.DefaultOptions()
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	athletesPath := flags.String("athletes", "", "path to the athletes file (JSON)")
+	width := flags.Int("width", envInt("COLUMNS", defaults.Width), "terminal width")
+	height := flags.Int("height", envInt("LINES", defaults.Height), "terminal height")
+	noColor := flags.Bool("no-color", false, "disable colors")
+	interval := flags.Duration("interval", 500*time.Millisecond, "redraw interval")
+	webhooksPath := flags.String("webhooks", "", "path to the webhooks file (JSON)")
+	reorder := flags.Duration("reorder", 0, "hold events back this long to apply them in time order")
+	dbPath := flags.String("db", "", "store the config, events and results in this SQLite file")
+	outgoingPath := flags.String("outgoing", "", "write each outgoing event to this file as it is produced")
+	language := languageFlag(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		fmt.Println("Usage: biathlon-tracker watch [-athletes athletes_file] [-width columns] [-height lines] [-no-color] [-interval duration] [-webhooks webhooks_file] [-reorder duration] [-db db_file] [-outgoing events_file] [-lang language] <config_file> <events_file|->")
+		os.Exit(1)
+	}
+
+	competition, err := newCompetition(flags.Arg(0), *athletesPath, *language)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if *webhooksPath != "" {
+		notifier, err := newNotifier(*webhooksPath)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		// Pending notifications are delivered after the dashboard is closed
+		defer notifier.Close()
+		competition.AddHandler(notifier)
+	}
+	// Storage errors are shown on the dashboard like processing errors
+	var lastErr error
+	if *dbPath != "" {
+		db, recorder, err := newRecorder(*dbPath, competition, func(err error) { lastErr = err })
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer db.Close()
+		// Runs after the dashboard is closed; the results change without a finish
+		// while competitors are still racing
+		defer func() {
+			if err := recorder.SaveResults(competition); err != nil {
+				fmt.Printf("Error storing results: %v\n", err)
+			}
+		}()
+	}
+	if *outgoingPath != "" {
+		file, feed, err := newOutgoingFeed(*outgoingPath, competition)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		// Runs after the dashboard is closed
+		defer func() {
+			if err := feed.Err(); err != nil {
+				fmt.Printf("Error writing outgoing events: %v\n", err)
+			}
+		}()
+	}
+	buffer := service.NewReorderBuffer(competition, *reorder)
+
+	// A file is followed like tail -f, stdin is read until it is closed
+	var source io.Reader = os.Stdin
+	follow := false
+	if path := flags.Arg(1); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Printf("Error opening events file: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		source = file
+		follow = true
+	}
+
+	lines := make(chan string)
+	go readLines(source, follow, lines)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	opts := defaults
+	opts.Width = *width
+	opts.Height = *height
+	opts.Color = !*noColor
+
+	fmt.Print(dashboard.SessionStart)
+	defer fmt.Print(dashboard.SessionEnd)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	dirty := true
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				// The stream ended, nothing can arrive late any more
+				lines = nil
+				if err := buffer.Flush(); err != nil {
+					lastErr = err
+				}
+				dirty = true
+				continue
+			}
+			event, err := domain.ParseEvent(line)
+			if errors.Is(err, domain.ErrInvalidEventFormat) {
+				continue
+			}
+			if err == nil {
+				err = buffer.Add(event)
+			}
+			if err != nil {
+				lastErr = err
+			}
+			dirty = true
+		case <-ticker.C:
+			// Held events are released after the delay even when no newer event comes
+			if pending := len(buffer.Pending()); pending > 0 {
+				if err := buffer.Expire(time.Now()); err != nil {
+					lastErr = err
+				}
+				dirty = dirty || len(buffer.Pending()) != pending
+			}
+			if !dirty {
+				continue
+			}
+			dirty = false
+			if err := dashboard.Render(os.Stdout, competition, opts); err != nil {
+				return
+			}
+			if lastErr != nil {
+				fmt.Printf("\r\nError processing event: %v", lastErr)
+			}
+		case <-signals:
+			return
+		}
+	}
+}
+
+// readLines sends every complete line of r to lines. When follow is set it keeps
+// polling for appended data at EOF, otherwise the channel is closed.
+func readLines(r io.Reader, follow bool, lines chan<- string) {
+	reader := bufio.NewReader(r)
+	partial := ""
+	for {
+		chunk, err := reader.ReadString('\n')
+		partial += chunk
+		if err == nil {
+			lines <- strings.TrimRight(partial, "\r\n")
+			partial = ""
+			continue
+		}
+		if err != io.EOF || !follow {
+			if partial != "" {
+				lines <- partial
+			}
+			close(lines)
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,357 @@
+package cup
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+var (
+	ErrInvalidCup       = errors.New("invalid cup")
+	ErrMissingAthleteID = errors.New("result without an athlete ID")
+	ErrDuplicateAthlete = errors.New("athlete listed twice in a race")
+)
+
+// DefaultPoints is the points table of the IBU World Cup, 90 points for the winner down to
+// a single point for the 40th place
+func DefaultPoints() []int {
+	points := []int{90, 75, 60, 50, 45, 40, 36, 34, 32, 31}
+	for p := 30; p >= 1; p-- {
+		points = append(points, p)
+	}
+	return points
+}
+
+// Race names a results file of the cup. Either Results points to a JSON results export
+// or Config and Events to the files of the race; paths are relative to the cup file.
+type Race struct {
+	Name     string `json:"name"`
+	Results  string `json:"results"`
+	Config   string `json:"config"`
+	Events   string `json:"events"`
+	Athletes string `json:"athletes"`
+}
+
+// Config describes a cup
+type Config struct {
+	Name      string `json:"name"`
+	Points    []int  `json:"points"`    // points by rank, DefaultPoints when empty
+	DropWorst int    `json:"dropWorst"` // number of worst results not counted
+	Races     []Race `json:"races"`
+}
+
+func (c *Config) Validate() error {
+	if err := c.validateScoring(len(c.Races)); err != nil {
+		return err
+	}
+	for i, race := range c.Races {
+		if race.Results == "" && (race.Config == "" || race.Events == "") {
+			return fmt.Errorf("%w: race %d needs a results file or a config and events file", ErrInvalidCup, i+1)
+		}
+	}
+	return nil
+}
+
+// validateScoring checks the points table and the drop-worst rule for the given number
+// of races
+func (c *Config) validateScoring(races int) error {
+	if races == 0 {
+		return fmt.Errorf("%w: no races", ErrInvalidCup)
+	}
+	if c.DropWorst < 0 || c.DropWorst >= races {
+		return fmt.Errorf("%w: dropWorst must be between 0 and %d", ErrInvalidCup, races-1)
+	}
+	for i, points := range c.Points {
+		if points < 0 {
+			return fmt.Errorf("%w: negative points for rank %d", ErrInvalidCup, i+1)
+		}
+	}
+	return nil
+}
+
+// RaceResult is the outcome of one race for an athlete
+type RaceResult struct {
+	Rank    int  `json:"rank,omitempty"` // 0 when the athlete did not finish or did not take part
+	Points  int  `json:"points"`
+	Dropped bool `json:"dropped,omitempty"` // not counted under the drop-worst rule
+}
+
+// Standing is a line of the cup standings
+type Standing struct {
+	Rank      int          `json:"rank"` // shared by athletes tied on points and placings
+	AthleteID string       `json:"athleteId"`
+	Name      string       `json:"name,omitempty"`
+	Category  string       `json:"category,omitempty"`
+	Points    int          `json:"points"`
+	Results   []RaceResult `json:"results"` // one per race, in race order
+}
+
+// CategoryStandings holds the standings of one category
+type CategoryStandings struct {
+	Category  string      `json:"category"`
+	Standings []*Standing `json:"standings"`
+}
+
+// Standings is the cup table after all races
+type Standings struct {
+	Name       string              `json:"name,omitempty"`
+	Races      []string            `json:"races"`
+	Overall    []*Standing         `json:"overall"`
+	Categories []CategoryStandings `json:"categories"`
+}
+
+// Compute awards points for every race and ranks the athletes by their total. Athletes
+// with the same race time share the rank and the points. Overall points are awarded by
+// the overall rank and category points by the rank within the category. The drop-worst
+// rule is checked against the races given, which need not match config.Races.
+func Compute(config *Config, races []*service.ResultsExport) (*Standings, error) {
+	if err := config.validateScoring(len(races)); err != nil {
+		return nil, err
+	}
+	points := config.Points
+	if len(points) == 0 {
+		points = DefaultPoints()
+	}
+
+	standings := &Standings{Name: config.Name, Races: make([]string, len(races))}
+	athletes := make(map[string]*athlete)
+	order := make([]string, 0)
+	for i, race := range races {
+		standings.Races[i] = race.Name
+		if i < len(config.Races) && config.Races[i].Name != "" {
+			standings.Races[i] = config.Races[i].Name
+		}
+		if standings.Races[i] == "" {
+			standings.Races[i] = fmt.Sprintf("Race %d", i+1)
+		}
+
+		seen := make(map[string]bool)
+		for _, result := range race.Results {
+			if result.AthleteID == "" {
+				return nil, fmt.Errorf("%w: competitor %d of %s", ErrMissingAthleteID, result.CompetitorID, standings.Races[i])
+			}
+			if seen[result.AthleteID] {
+				return nil, fmt.Errorf("%w: %s in %s", ErrDuplicateAthlete, result.AthleteID, standings.Races[i])
+			}
+			seen[result.AthleteID] = true
+
+			a, ok := athletes[result.AthleteID]
+			if !ok {
+				a = &athlete{id: result.AthleteID, overall: make([]int, len(races)), category: make([]int, len(races))}
+				athletes[result.AthleteID] = a
+				order = append(order, result.AthleteID)
+			}
+			// The latest race decides the name and the category
+			if result.Name != "" {
+				a.name = result.Name
+			}
+			if result.Category != "" {
+				a.group = result.Category
+			}
+		}
+
+		overall, err := raceRanks(race.Results, func(service.ExportedResult) string { return "" })
+		if err != nil {
+			return nil, err
+		}
+		byCategory, err := raceRanks(race.Results, func(result service.ExportedResult) string { return result.Category })
+		if err != nil {
+			return nil, err
+		}
+		for id, rank := range overall {
+			athletes[id].overall[i] = rank
+		}
+		for id, rank := range byCategory {
+			athletes[id].category[i] = rank
+		}
+	}
+
+	groups := make(map[string][]*Standing)
+	for _, id := range order {
+		a := athletes[id]
+		standings.Overall = append(standings.Overall, a.standing(a.overall, points, config.DropWorst))
+		groups[a.group] = append(groups[a.group], a.standing(a.category, points, config.DropWorst))
+	}
+	rank(standings.Overall)
+
+	categories := make([]string, 0, len(groups))
+	for category := range groups {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i] == "" || categories[j] == "" {
+			return categories[j] == ""
+		}
+		return categories[i] < categories[j]
+	})
+	for _, category := range categories {
+		rank(groups[category])
+		standings.Categories = append(standings.Categories, CategoryStandings{Category: category, Standings: groups[category]})
+	}
+	return standings, nil
+}
+
+type athlete struct {
+	id       string
+	name     string
+	group    string
+	overall  []int // race ranks
+	category []int
+}
+
+func (a *athlete) standing(ranks []int, points []int, dropWorst int) *Standing {
+	standing := &Standing{
+		AthleteID: a.id,
+		Name:      a.name,
+		Category:  a.group,
+		Results:   make([]RaceResult, len(ranks)),
+	}
+	for i, rank := range ranks {
+		standing.Results[i].Rank = rank
+		if rank > 0 && rank <= len(points) {
+			standing.Results[i].Points = points[rank-1]
+		}
+	}
+
+	// Drop the lowest scores, the earliest race first among equal scores
+	races := make([]int, len(ranks))
+	for i := range races {
+		races[i] = i
+	}
+	sort.SliceStable(races, func(i, j int) bool {
+		return standing.Results[races[i]].Points < standing.Results[races[j]].Points
+	})
+	for _, race := range races[:dropWorst] {
+		standing.Results[race].Dropped = true
+	}
+	for _, result := range standing.Results {
+		if !result.Dropped {
+			standing.Points += result.Points
+		}
+	}
+	return standing
+}
+
+// raceRanks ranks the finishers of a race by time within the groups returned by group.
+// Finishers with the same time share the better rank.
+func raceRanks(results []service.ExportedResult, group func(service.ExportedResult) string) (map[string]int, error) {
+	type finisher struct {
+		id   string
+		time time.Duration
+	}
+	groups := make(map[string][]finisher)
+	for _, result := range results {
+		if result.Rank == 0 {
+			continue
+		}
+		raceTime, err := service.ParseDuration(result.Time)
+		if err != nil {
+			return nil, fmt.Errorf("athlete %s: %v", result.AthleteID, err)
+		}
+		groups[group(result)] = append(groups[group(result)], finisher{result.AthleteID, raceTime})
+	}
+
+	ranks := make(map[string]int)
+	for _, finishers := range groups {
+		sort.SliceStable(finishers, func(i, j int) bool { return finishers[i].time < finishers[j].time })
+		for i, f := range finishers {
+			ranks[f.id] = i + 1
+			if i > 0 && f.time == finishers[i-1].time {
+				ranks[f.id] = ranks[finishers[i-1].id]
+			}
+		}
+	}
+	return ranks, nil
+}
+
+// rank orders standings by points. Ties are broken by the better placings (most wins,
+// then most second places and so on); athletes still tied share the rank.
+func rank(standings []*Standing) {
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Points != standings[j].Points {
+			return standings[i].Points > standings[j].Points
+		}
+		return countback(standings[i], standings[j]) > 0
+	})
+	for i, standing := range standings {
+		standing.Rank = i + 1
+		if i > 0 {
+			previous := standings[i-1]
+			if previous.Points == standing.Points && countback(previous, standing) == 0 {
+				standing.Rank = previous.Rank
+			}
+		}
+	}
+}
+
+// countback compares the placings of two athletes, positive when a placed better
+func countback(a, b *Standing) int {
+	placings := func(standing *Standing) map[int]int {
+		counts := make(map[int]int)
+		for _, result := range standing.Results {
+			if result.Rank > 0 {
+				counts[result.Rank]++
+			}
+		}
+		return counts
+	}
+	countsA, countsB := placings(a), placings(b)
+	ranks := make([]int, 0, len(countsA)+len(countsB))
+	for rank := range countsA {
+		ranks = append(ranks, rank)
+	}
+	for rank := range countsB {
+		ranks = append(ranks, rank)
+	}
+	sort.Ints(ranks)
+	for _, rank := range ranks {
+		if countsA[rank] != countsB[rank] {
+			return countsA[rank] - countsB[rank]
+		}
+	}
+	return 0
+}
+
+// String renders the overall and category standings as text
+func (s *Standings) String() string {
+	var b strings.Builder
+	if s.Name != "" {
+		b.WriteString(s.Name + "\n")
+	}
+	b.WriteString("Races: " + strings.Join(s.Races, ", ") + "\n")
+	b.WriteString("\nOverall\n")
+	writeStandings(&b, s.Overall)
+	for _, group := range s.Categories {
+		category := group.Category
+		if category == "" {
+			category = "No category"
+		}
+		b.WriteString("\n" + category + "\n")
+		writeStandings(&b, group.Standings)
+	}
+	return b.String()
+}
+
+func writeStandings(b *strings.Builder, standings []*Standing) {
+	for _, standing := range standings {
+		name := standing.AthleteID
+		if standing.Name != "" {
+			name = standing.Name
+		}
+		results := make([]string, len(standing.Results))
+		for i, result := range standing.Results {
+			results[i] = "-"
+			if result.Rank > 0 {
+				results[i] = fmt.Sprintf("%d", result.Points)
+			}
+			if result.Dropped {
+				results[i] = "(" + results[i] + ")"
+			}
+		}
+		fmt.Fprintf(b, "%d. %s %d [%s]\n", standing.Rank, name, standing.Points, strings.Join(results, " "))
+	}
+}
@@ -0,0 +1,188 @@
+package cup
+
+import (
+	"testing"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func finisher(rank int, id, name, category, raceTime string) service.ExportedResult {
+	return service.ExportedResult{Rank: rank, AthleteID: id, Name: name, Category: category, Status: "Finished", Time: raceTime}
+}
+
+// testRaces are three races of four athletes: anna wins twice, berit and carl tie on
+// time in the second race and dina misses the first race
+func testRaces() []*service.ResultsExport {
+	return []*service.ResultsExport{
+		{Name: "Sprint", Results: []service.ExportedResult{
+			finisher(1, "anna", "Anna", "Women", "00:20:00.000"),
+			finisher(2, "carl", "Carl", "Men", "00:20:30.000"),
+			finisher(3, "berit", "Berit", "Women", "00:21:00.000"),
+		}},
+		{Name: "Individual", Results: []service.ExportedResult{
+			finisher(1, "dina", "Dina", "Women", "00:40:00.000"),
+			finisher(2, "berit", "Berit", "Women", "00:41:00.000"),
+			finisher(3, "carl", "Carl", "Men", "00:41:00.000"),
+			{AthleteID: "anna", Name: "Anna", Category: "Women", Status: "NotFinished"},
+		}},
+		{Name: "Pursuit", Results: []service.ExportedResult{
+			finisher(1, "anna", "Anna", "Women", "00:30:00.000"),
+			finisher(2, "dina", "Dina", "Women", "00:30:10.000"),
+			finisher(3, "berit", "Berit", "Women", "00:30:20.000"),
+			finisher(4, "carl", "Carl", "Men", "00:31:00.000"),
+		}},
+	}
+}
+
+func testConfig() *Config {
+	races := []Race{{Results: "sprint.json"}, {Results: "individual.json"}, {Results: "pursuit.json"}}
+	return &Config{Name: "Club Cup", Points: []int{10, 8, 6, 5}, Races: races}
+}
+
+func byID(standings []*Standing) map[string]*Standing {
+	result := make(map[string]*Standing)
+	for _, standing := range standings {
+		result[standing.AthleteID] = standing
+	}
+	return result
+}
+
+func TestCompute(t *testing.T) {
+	standings, err := Compute(testConfig(), testRaces())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Sprint", "Individual", "Pursuit"}, standings.Races)
+	require.Len(t, standings.Overall, 4)
+
+	// berit and carl share second place in the individual
+	athletes := byID(standings.Overall)
+	assert.Equal(t, 8, athletes["berit"].Results[1].Points)
+	assert.Equal(t, 2, athletes["carl"].Results[1].Rank)
+	assert.Equal(t, 0, athletes["anna"].Results[1].Points)
+
+	// carl's 8+8+5 beats anna's two wins
+	assert.Equal(t, "carl", standings.Overall[0].AthleteID)
+	assert.Equal(t, 21, standings.Overall[0].Points)
+	assert.Equal(t, "anna", standings.Overall[1].AthleteID)
+	assert.Equal(t, 20, standings.Overall[1].Points)
+	assert.Equal(t, 20, standings.Overall[2].Points)
+	assert.Equal(t, "dina", standings.Overall[3].AthleteID)
+	assert.Equal(t, 18, standings.Overall[3].Points)
+}
+
+func TestComputeCountback(t *testing.T) {
+	standings, err := Compute(testConfig(), testRaces())
+	require.NoError(t, err)
+
+	// anna and berit both have 20 points, anna's two wins decide
+	athletes := byID(standings.Overall)
+	assert.Equal(t, 2, athletes["anna"].Rank)
+	assert.Equal(t, 3, athletes["berit"].Rank)
+}
+
+func TestComputeSharedRank(t *testing.T) {
+	races := []*service.ResultsExport{{Name: "Sprint", Results: []service.ExportedResult{
+		finisher(1, "anna", "Anna", "", "00:20:00.000"),
+		finisher(2, "berit", "Berit", "", "00:20:00.000"),
+		finisher(3, "carl", "Carl", "", "00:21:00.000"),
+	}}}
+	config := &Config{Points: []int{10, 8, 6}, Races: []Race{{Results: "sprint.json"}}}
+	standings, err := Compute(config, races)
+	require.NoError(t, err)
+
+	ranks := []int{standings.Overall[0].Rank, standings.Overall[1].Rank, standings.Overall[2].Rank}
+	assert.Equal(t, []int{1, 1, 3}, ranks)
+	assert.Equal(t, 10, standings.Overall[1].Points)
+}
+
+func TestComputeDropWorst(t *testing.T) {
+	config := testConfig()
+	config.DropWorst = 1
+	standings, err := Compute(config, testRaces())
+	require.NoError(t, err)
+
+	athletes := byID(standings.Overall)
+	// anna drops the race she did not finish, dina the one she missed
+	assert.True(t, athletes["anna"].Results[1].Dropped)
+	assert.Equal(t, 20, athletes["anna"].Points)
+	assert.True(t, athletes["dina"].Results[0].Dropped)
+	assert.Equal(t, 18, athletes["dina"].Points)
+	// carl's worst result is the pursuit
+	assert.True(t, athletes["carl"].Results[2].Dropped)
+	assert.Equal(t, 16, athletes["carl"].Points)
+	assert.Equal(t, 14, athletes["berit"].Points)
+	assert.Equal(t, "dina", standings.Overall[1].AthleteID)
+}
+
+func TestComputeCategories(t *testing.T) {
+	standings, err := Compute(testConfig(), testRaces())
+	require.NoError(t, err)
+	require.Len(t, standings.Categories, 2)
+
+	men := standings.Categories[0]
+	assert.Equal(t, "Men", men.Category)
+	require.Len(t, men.Standings, 1)
+	assert.Equal(t, 30, men.Standings[0].Points)
+
+	women := standings.Categories[1]
+	assert.Equal(t, "Women", women.Category)
+	// In the women's ranking the sprint gives berit second place
+	berit := byID(women.Standings)["berit"]
+	assert.Equal(t, 2, berit.Results[0].Rank)
+	assert.Equal(t, 8, berit.Results[0].Points)
+}
+
+func TestComputeErrors(t *testing.T) {
+	races := testRaces()
+	races[0].Results[0].AthleteID = ""
+	_, err := Compute(testConfig(), races)
+	assert.ErrorIs(t, err, ErrMissingAthleteID)
+
+	races = testRaces()
+	races[0].Results[1].AthleteID = "anna"
+	_, err = Compute(testConfig(), races)
+	assert.ErrorIs(t, err, ErrDuplicateAthlete)
+
+	// Dropping as many results as there are races is refused rather than panicking
+	config := testConfig()
+	config.DropWorst = 2
+	_, err = Compute(config, testRaces()[:2])
+	assert.ErrorIs(t, err, ErrInvalidCup)
+
+	_, err = Compute(testConfig(), nil)
+	assert.ErrorIs(t, err, ErrInvalidCup)
+
+	config = testConfig()
+	config.Points = []int{10, -1}
+	_, err = Compute(config, testRaces())
+	assert.ErrorIs(t, err, ErrInvalidCup)
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, testConfig().Validate())
+
+	config := testConfig()
+	config.DropWorst = 3
+	assert.ErrorIs(t, config.Validate(), ErrInvalidCup)
+
+	config = testConfig()
+	config.Races = append(config.Races, Race{Config: "config.json"})
+	assert.ErrorIs(t, config.Validate(), ErrInvalidCup)
+
+	assert.ErrorIs(t, (&Config{}).Validate(), ErrInvalidCup)
+	assert.Len(t, DefaultPoints(), 40)
+}
+
+func TestStandingsString(t *testing.T) {
+	config := testConfig()
+	config.DropWorst = 1
+	standings, err := Compute(config, testRaces())
+	require.NoError(t, err)
+
+	text := standings.String()
+	assert.Contains(t, text, "Club Cup\nRaces: Sprint, Individual, Pursuit\n\nOverall\n")
+	assert.Contains(t, text, "1. Anna 20 [10 (-) 10]\n")
+	assert.Contains(t, text, "2. Dina 18 [(-) 10 8]\n")
+	assert.Contains(t, text, "\nMen\n1. Carl 20 [(10) 10 10]\n")
+}
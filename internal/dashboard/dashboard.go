@@ -0,0 +1,185 @@
+package dashboard
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	bold        = "\x1b[1m"
+	highlight   = "\x1b[1;32m"
+	reset       = "\x1b[0m"
+
+	// SessionStart switches to the alternate screen and hides the cursor,
+	// SessionEnd restores the terminal when the dashboard is closed
+	SessionStart = "\x1b[?1049h\x1b[?25l"
+	SessionEnd   = "\x1b[?25h\x1b[?1049l"
+)
+
+// Options controls the layout of the dashboard
+type Options struct {
+	Width     int
+	Height    int
+	Color     bool
+	Finishers int // number of latest finishers to highlight
+	LogLines  int
+}
+
+// DefaultOptions returns the layout of a standard 80x24 terminal
+func DefaultOptions() Options {
+	return Options{
+		Width:     80,
+		Height:    24,
+		Color:     true,
+		Finishers: 3,
+		LogLines:  6,
+	}
+}
+
+type frame struct {
+	lines []string
+	opts  Options
+}
+
+func (f *frame) add(text string) {
+	runes := []rune(text)
+	if len(runes) > f.opts.Width {
+		runes = runes[:f.opts.Width]
+	}
+	f.lines = append(f.lines, string(runes))
+}
+
+func (f *frame) addStyled(style, text string) {
+	if !f.opts.Color {
+		f.add(text)
+		return
+	}
+	f.add(text)
+	f.lines[len(f.lines)-1] = style + f.lines[len(f.lines)-1] + reset
+}
+
+// Render draws a full frame of the dashboard for the current state of the competition
+func Render(w io.Writer, competition *service.CompetitionService, opts Options) error {
+	f := &frame{opts: opts}
+	competitors := competition.GetCompetitors()
+
+	title := competition.Config().Name
+	if title == "" {
+		title = "Biathlon tracker"
+	}
+	clock := "--:--:--.---"
+	if last := competition.GetLastEventTime(); !last.IsZero() {
+		clock = last.Format("15:04:05.000")
+	}
+	f.addStyled(bold, fmt.Sprintf("%-*s%s", opts.Width-len(clock), title, clock))
+	f.add(summary(competitors))
+	f.add("")
+
+	locations := locationLines(competitors)
+	logLines := competition.GetRecentLog(opts.LogLines)
+
+	// Whatever is left after the fixed sections is given to the standings
+	rows := opts.Height - len(f.lines) - 3 - len(locations) - 2 - len(logLines)
+	f.addStyled(bold, "STANDINGS")
+	f.add(fmt.Sprintf("%3s %4s  %-24s %5s  %-12s  %s", "Pos", "Bib", "Name", "Lap", "Time", "Gap"))
+	standings := competition.GetStandings()
+	recent := make(map[int]bool)
+	for _, competitor := range competition.GetRecentFinishers(opts.Finishers) {
+		recent[competitor.ID] = true
+	}
+	for i, standing := range standings {
+		if i >= rows {
+			break
+		}
+		line := standingLine(standing)
+		if recent[standing.Competitor.ID] {
+			if opts.Color {
+				f.addStyled(highlight, line)
+			} else {
+				f.add(line + " *")
+			}
+			continue
+		}
+		f.add(line)
+	}
+	f.add("")
+
+	for _, line := range locations {
+		f.add(line)
+	}
+	f.add("")
+
+	f.addStyled(bold, "RECENT EVENTS")
+	for _, line := range logLines {
+		f.add(line)
+	}
+
+	_, err := io.WriteString(w, clearScreen+strings.Join(f.lines, "\r\n"))
+	return err
+}
+
+func standingLine(standing *service.Standing) string {
+	competitor := standing.Competitor
+	lap := fmt.Sprintf("%d/%d", standing.Laps, standing.TotalLaps)
+	if competitor.Status == domain.StatusFinished {
+		lap = "F"
+	}
+	gap := ""
+	if standing.Position > 1 {
+		gap = "+" + service.FormatDuration(standing.Gap)
+	}
+	return fmt.Sprintf("%3d %4d  %-24s %5s  %s  %s",
+		standing.Position, competitor.ID, competitor.Name(), lap, service.FormatDuration(standing.Time), gap)
+}
+
+func summary(competitors []*domain.Competitor) string {
+	counts := make(map[domain.CompetitorStatus]int)
+	for _, competitor := range competitors {
+		counts[competitor.Status]++
+	}
+	return fmt.Sprintf("Registered %d  Course %d  Range %d  Penalty %d  Finished %d  DNF %d",
+		len(competitors),
+		counts[domain.StatusRacing],
+		counts[domain.StatusOnFiringRange],
+		counts[domain.StatusOnPenaltyLaps],
+		counts[domain.StatusFinished],
+		counts[domain.StatusNotFinished]+counts[domain.StatusDisqualified])
+}
+
+// locationLines lists who is currently on the start line, the course, the range and the penalty loop
+func locationLines(competitors []*domain.Competitor) []string {
+	sections := []struct {
+		title  string
+		status domain.CompetitorStatus
+	}{
+		{"Start line", domain.StatusOnStartLine},
+		{"On course ", domain.StatusRacing},
+		{"On range  ", domain.StatusOnFiringRange},
+		{"Penalty   ", domain.StatusOnPenaltyLaps},
+	}
+
+	lines := make([]string, 0, len(sections))
+	for _, section := range sections {
+		labels := make([]string, 0)
+		for _, competitor := range competitors {
+			if competitor.Status != section.status {
+				continue
+			}
+			label := fmt.Sprintf("%d", competitor.ID)
+			if name := competitor.Name(); name != "" {
+				label += " " + name
+			}
+			if section.status == domain.StatusOnFiringRange && len(competitor.Ranges) > 0 {
+				label += fmt.Sprintf(" (line %d)", competitor.Ranges[len(competitor.Ranges)-1].Line)
+			}
+			labels = append(labels, label)
+		}
+		lines = append(lines, section.title+"  "+strings.Join(labels, ", "))
+	}
+	return lines
+}
@@ -0,0 +1,94 @@
+package dashboard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCompetition(t *testing.T) *service.CompetitionService {
+	t.Helper()
+	competition := service.NewCompetitionService(&domain.Config{
+		Name:        "Mass start",
+		Laps:        1,
+		LapLen:      3000,
+		PenaltyLen:  150,
+		FiringLines: 2,
+		Start:       "10:00:00.000",
+		StartDelta:  "00:00:00.000",
+	})
+	athletes, err := domain.NewAthleteRegistry([]*domain.Athlete{{CompetitorID: 1, Name: "Ingrid Solberg"}})
+	require.NoError(t, err)
+	competition.SetAthletes(athletes)
+
+	events := []*domain.Event{
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 2, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 3, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 4, ""),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 2, ""),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 3, ""),
+		testutil.Incoming("10:00:00.000", domain.EventOnStartLine, 4, ""),
+		testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 2, "2"),
+		testutil.Incoming("10:06:00.000", domain.EventEnteredPenaltyLaps, 3, ""),
+		testutil.Incoming("10:10:00.000", domain.EventEndedMainLap, 1, ""),
+	}
+	for _, event := range events {
+		require.NoError(t, competition.ProcessEvent(event))
+	}
+	return competition
+}
+
+func TestRender(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Color = false
+
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, newTestCompetition(t), opts))
+	frame := buf.String()
+
+	assert.True(t, strings.HasPrefix(frame, clearScreen))
+	lines := strings.Split(strings.TrimPrefix(frame, clearScreen), "\r\n")
+	assert.LessOrEqual(t, len(lines), opts.Height)
+	for _, line := range lines {
+		assert.LessOrEqual(t, len([]rune(line)), opts.Width)
+	}
+
+	assert.Contains(t, lines[0], "Mass start")
+	assert.Contains(t, lines[0], "10:10:00.000")
+	assert.Contains(t, frame, "Registered 4  Course 0  Range 1  Penalty 1  Finished 1  DNF 0")
+	assert.Contains(t, frame, "  1    1  Ingrid Solberg")
+	assert.Contains(t, frame, "00:10:00.000   *")
+	assert.Contains(t, frame, "Start line  4")
+	assert.Contains(t, frame, "On range    2 (line 2)")
+	assert.Contains(t, frame, "Penalty     3")
+	assert.Contains(t, frame, "The competitor(1, Ingrid Solberg) has finished")
+	assert.NotContains(t, frame, highlight)
+}
+
+func TestRender_Color(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, newTestCompetition(t), DefaultOptions()))
+	assert.Contains(t, buf.String(), highlight+"  1    1  Ingrid Solberg")
+}
+
+func TestRender_SmallTerminal(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Width = 30
+	opts.Height = 12
+
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, newTestCompetition(t), opts))
+	lines := strings.Split(strings.TrimPrefix(buf.String(), clearScreen), "\r\n")
+	for _, line := range lines {
+		line = strings.NewReplacer(bold, "", highlight, "", reset, "").Replace(line)
+		assert.LessOrEqual(t, len([]rune(line)), opts.Width)
+	}
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Age groups by the age an athlete reaches in the year of the race
+const (
+	youthAge   = 17 // U17 below
+	juniorAge  = 19 // U19 below
+	veteranAge = 40 // Veteran from
+)
+
+// Athlete represents the person behind a competitor number
+type Athlete struct {
+	ID           string `json:"id"`
+	CompetitorID int    `json:"competitorId"`
+	Bib          int    `json:"bib"`
+	Name         string `json:"name"`
+	Nation       string `json:"nation"`
+	Club         string `json:"club"`
+	Gender       string `json:"gender"`
+	BirthYear    int    `json:"birthYear"`
+	Category     string `json:"category"`
+}
+
+// GenderGroup returns Men or Women from the athlete's gender, empty when it is not set
+func (a *Athlete) GenderGroup() string {
+	switch a.Gender {
+	case "M":
+		return "Men"
+	case "W", "F":
+		return "Women"
+	}
+	return ""
+}
+
+// AgeGroup returns U17, U19, Senior or Veteran from the age the athlete reaches in the
+// given year, empty when the birth year or the year is not known
+func (a *Athlete) AgeGroup(year int) string {
+	if a.BirthYear <= 0 || year <= 0 {
+		return ""
+	}
+	switch age := year - a.BirthYear; {
+	case age < youthAge:
+		return "U17"
+	case age < juniorAge:
+		return "U19"
+	case age < veteranAge:
+		return "Senior"
+	}
+	return "Veteran"
+}
+
+// DerivedGroup returns the ranking group from the athlete's gender and age group, such
+// as "Men U19", for athletes without an explicit category
+func (a *Athlete) DerivedGroup(year int) string {
+	return strings.TrimSpace(a.GenderGroup() + " " + a.AgeGroup(year))
+}
+
+// Affiliation returns the club and nation of the athlete in a single string
+func (a *Athlete) Affiliation() string {
+	switch {
+	case a.Club != "" && a.Nation != "":
+		return fmt.Sprintf("%s (%s)", a.Club, a.Nation)
+	case a.Club != "":
+		return a.Club
+	default:
+		return a.Nation
+	}
+}
+
+func (a *Athlete) Validate() error {
+	if a.CompetitorID <= 0 {
+		return fmt.Errorf("%w: competitor id must be positive", ErrInvalidAthlete)
+	}
+	if a.Name == "" {
+		return fmt.Errorf("%w: competitor %d has no name", ErrInvalidAthlete, a.CompetitorID)
+	}
+	return nil
+}
+
+// AthleteRegistry maps competitor IDs to athlete profiles
+type AthleteRegistry map[int]*Athlete
+
+// NewAthleteRegistry builds a registry from a list of athletes, rejecting
+// invalid profiles and competitor numbers assigned twice
+func NewAthleteRegistry(athletes []*Athlete) (AthleteRegistry, error) {
+	registry := make(AthleteRegistry, len(athletes))
+	for _, athlete := range athletes {
+		if err := athlete.Validate(); err != nil {
+			return nil, err
+		}
+		if _, exists := registry[athlete.CompetitorID]; exists {
+			return nil, fmt.Errorf("%w: competitor %d", ErrDuplicateAthlete, athlete.CompetitorID)
+		}
+		if athlete.Bib == 0 {
+			athlete.Bib = athlete.CompetitorID
+		}
+		registry[athlete.CompetitorID] = athlete
+	}
+	return registry, nil
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAthlete_AgeGroup(t *testing.T) {
+	tests := []struct {
+		birthYear int
+		expected  string
+	}{
+		{2008, "U17"},
+		{2007, "U19"},
+		{2006, "U19"},
+		{2005, "Senior"},
+		{1985, "Senior"},
+		{1984, "Veteran"},
+		{0, ""},
+	}
+
+	for _, tt := range tests {
+		athlete := &Athlete{BirthYear: tt.birthYear}
+		assert.Equal(t, tt.expected, athlete.AgeGroup(2024), tt.birthYear)
+	}
+	assert.Equal(t, "", (&Athlete{BirthYear: 2008}).AgeGroup(0))
+}
+
+func TestAthlete_Affiliation(t *testing.T) {
+	assert.Equal(t, "SC Ruhpolding (GER)", (&Athlete{Club: "SC Ruhpolding", Nation: "GER"}).Affiliation())
+	assert.Equal(t, "SC Ruhpolding", (&Athlete{Club: "SC Ruhpolding"}).Affiliation())
+	assert.Equal(t, "GER", (&Athlete{Nation: "GER"}).Affiliation())
+}
+
+func TestNewAthleteRegistry(t *testing.T) {
+	registry, err := NewAthleteRegistry([]*Athlete{
+		{CompetitorID: 1, Name: "Ingrid Solberg"},
+		{CompetitorID: 2, Bib: 12, Name: "Jonas Keller"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, registry, 2)
+	assert.Equal(t, 1, registry[1].Bib)
+	assert.Equal(t, 12, registry[2].Bib)
+
+	_, err = NewAthleteRegistry([]*Athlete{
+		{CompetitorID: 1, Name: "Ingrid Solberg"},
+		{CompetitorID: 1, Name: "Jonas Keller"},
+	})
+	assert.ErrorIs(t, err, ErrDuplicateAthlete)
+
+	_, err = NewAthleteRegistry([]*Athlete{{CompetitorID: 1}})
+	assert.ErrorIs(t, err, ErrInvalidAthlete)
+
+	_, err = NewAthleteRegistry([]*Athlete{{Name: "Nobody"}})
+	assert.ErrorIs(t, err, ErrInvalidAthlete)
+}
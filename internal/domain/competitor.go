@@ -22,6 +22,7 @@ type LapInfo struct {
 	Time     time.Duration
 	Speed    float64 // m/s
 	LapIndex int
+	End      time.Time
 }
 
 // PenaltyInfo represents information about penalty laps
@@ -30,20 +31,41 @@ type PenaltyInfo struct {
 	Speed float64 // m/s
 }
 
+// TargetsPerRange is the number of targets on each firing line visit
+const TargetsPerRange = 5
+
+// RangeInfo represents a single visit to the firing range
+type RangeInfo struct {
+	Line    int
+	Hits    int
+	Entered time.Time
+	Time    time.Duration
+}
+
+// Misses returns the number of targets missed during the visit
+func (r RangeInfo) Misses() int {
+	return TargetsPerRange - r.Hits
+}
+
 // Competitor represents a biathlon competitor
 type Competitor struct {
 	ID            int
+	Athlete       *Athlete
 	Status        CompetitorStatus
 	StartTime     time.Time
 	PlannedStart  time.Time
+	FinishTime    time.Time
 	Laps          []LapInfo
 	Penalties     []PenaltyInfo
+	Ranges        []RangeInfo
 	CurrentLap    int
 	Hits          int
 	Shots         int
 	Comment       string
 	DisqualReason string
 	TotalTime     time.Duration
+	PenaltyStart  time.Time
+	Warnings      []string // timing problems an official should look at
 }
 
 // NewCompetitor creates a new competitor
@@ -53,6 +75,7 @@ func NewCompetitor(id int) *Competitor {
 		Status:     StatusRegistered,
 		Laps:       make([]LapInfo, 0),
 		Penalties:  make([]PenaltyInfo, 0),
+		Ranges:     make([]RangeInfo, 0),
 		CurrentLap: 0,
 	}
 }
@@ -77,10 +100,43 @@ func (c *Competitor) AddPenalty(penaltyTime time.Duration, speed float64) {
 	c.TotalTime += penaltyTime
 }
 
+// EnterRange records the start of a visit to the given firing line
+func (c *Competitor) EnterRange(line int, at time.Time) {
+	c.Ranges = append(c.Ranges, RangeInfo{
+		Line:    line,
+		Entered: at,
+	})
+}
+
+// LeaveRange records the end of the current firing range visit
+func (c *Competitor) LeaveRange(at time.Time) {
+	if len(c.Ranges) == 0 {
+		return
+	}
+	current := &c.Ranges[len(c.Ranges)-1]
+	current.Time = at.Sub(current.Entered)
+}
+
 // RecordShot records a shot attempt
 func (c *Competitor) RecordShot(hit bool) {
 	c.Shots++
 	if hit {
 		c.Hits++
+		if len(c.Ranges) > 0 {
+			c.Ranges[len(c.Ranges)-1].Hits++
+		}
+	}
+}
+
+// Warn records a timing problem of the competitor
+func (c *Competitor) Warn(message string) {
+	c.Warnings = append(c.Warnings, message)
+}
+
+// Name returns the athlete's name, or an empty string if the competitor has no profile
+func (c *Competitor) Name() string {
+	if c.Athlete == nil {
+		return ""
 	}
+	return c.Athlete.Name
 }
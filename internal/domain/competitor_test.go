@@ -14,6 +14,7 @@ func TestNewCompetitor(t *testing.T) {
 	assert.Equal(t, StatusRegistered, competitor.Status)
 	assert.NotNil(t, competitor.Laps)
 	assert.NotNil(t, competitor.Penalties)
+	assert.NotNil(t, competitor.Ranges)
 	assert.Equal(t, 0, competitor.CurrentLap)
 	assert.Equal(t, 0, competitor.Hits)
 	assert.Equal(t, 0, competitor.Shots)
@@ -64,3 +65,29 @@ func TestRecordShot(t *testing.T) {
 	assert.Equal(t, 1, competitor.Hits)
 	assert.Equal(t, 2, competitor.Shots)
 }
+
+func TestFiringRanges(t *testing.T) {
+	competitor := NewCompetitor(1)
+	entered := time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC)
+
+	// Hits outside of a range visit only count towards the totals
+	competitor.RecordShot(true)
+	assert.Empty(t, competitor.Ranges)
+
+	competitor.EnterRange(1, entered)
+	competitor.RecordShot(true)
+	competitor.RecordShot(true)
+	competitor.LeaveRange(entered.Add(30 * time.Second))
+
+	competitor.EnterRange(2, entered.Add(10*time.Minute))
+	competitor.RecordShot(true)
+
+	assert.Len(t, competitor.Ranges, 2)
+	assert.Equal(t, 1, competitor.Ranges[0].Line)
+	assert.Equal(t, 2, competitor.Ranges[0].Hits)
+	assert.Equal(t, 3, competitor.Ranges[0].Misses())
+	assert.Equal(t, 30*time.Second, competitor.Ranges[0].Time)
+	assert.Equal(t, 2, competitor.Ranges[1].Line)
+	assert.Equal(t, 1, competitor.Ranges[1].Hits)
+	assert.Equal(t, 4, competitor.Hits)
+}
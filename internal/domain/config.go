@@ -6,12 +6,190 @@ import (
 )
 
 type Config struct {
-	Laps        int    `json:"laps"`
-	LapLen      int    `json:"lapLen"`
-	PenaltyLen  int    `json:"penaltyLen"`
-	FiringLines int    `json:"firingLines"`
-	Start       string `json:"start"`
-	StartDelta  string `json:"startDelta"`
+	Name           string           `json:"name,omitempty"`
+	Date           string           `json:"date,omitempty"`
+	Venue          string           `json:"venue,omitempty"`
+	Jury           []JuryMember     `json:"jury,omitempty"`
+	Weather        string           `json:"weather,omitempty"`
+	TimingProvider string           `json:"timingProvider,omitempty"`
+	Laps           int              `json:"laps"`
+	LapLen         int              `json:"lapLen"`
+	PenaltyLen     int              `json:"penaltyLen"`
+	FiringLines    int              `json:"firingLines"`
+	Start          string           `json:"start"`
+	StartDelta     string           `json:"startDelta"`
+	Categories     []CategoryConfig `json:"categories,omitempty"`
+	SpeedLimits    *SpeedLimits     `json:"speedLimits,omitempty"`
+	Duplicates     *DuplicateRules  `json:"duplicates,omitempty"`
+}
+
+// JuryMember is an official who signs the result sheets
+type JuryMember struct {
+	Role string `json:"role"`
+	Name string `json:"name"`
+}
+
+// CategoryConfig overrides the race rules for one category.
+// Zero values fall back to the values of the race itself.
+type CategoryConfig struct {
+	Name        string `json:"name"`
+	Laps        int    `json:"laps,omitempty"`
+	LapLen      int    `json:"lapLen,omitempty"`
+	PenaltyLen  int    `json:"penaltyLen,omitempty"`
+	Competitors []int  `json:"competitors,omitempty"`
+}
+
+// SpeedRange bounds a plausible speed in m/s; a zero bound is not checked
+type SpeedRange struct {
+	Min float64 `json:"min,omitempty"`
+	Max float64 `json:"max,omitempty"`
+}
+
+// Contains reports whether speed lies within the range
+func (r SpeedRange) Contains(speed float64) bool {
+	if r.Min > 0 && speed < r.Min {
+		return false
+	}
+	if r.Max > 0 && speed > r.Max {
+		return false
+	}
+	return true
+}
+
+func (r SpeedRange) String() string {
+	switch {
+	case r.Min > 0 && r.Max > 0:
+		return fmt.Sprintf("%.1f-%.1f m/s", r.Min, r.Max)
+	case r.Max > 0:
+		return fmt.Sprintf("at most %.1f m/s", r.Max)
+	default:
+		return fmt.Sprintf("at least %.1f m/s", r.Min)
+	}
+}
+
+// SpeedLimits holds the plausible speeds per segment type. The penalty range
+// applies to the distance actually skied, the penalty loop length times the misses.
+type SpeedLimits struct {
+	Lap     SpeedRange `json:"lap"`
+	Penalty SpeedRange `json:"penalty"`
+}
+
+// DefaultSpeedLimits returns generous bounds; world cup athletes average about 7 m/s
+func DefaultSpeedLimits() SpeedLimits {
+	return SpeedLimits{
+		Lap:     SpeedRange{Min: 1, Max: 12},
+		Penalty: SpeedRange{Min: 0.5, Max: 12},
+	}
+}
+
+// Limits returns the configured speed limits or the defaults
+func (c *Config) Limits() SpeedLimits {
+	if c.SpeedLimits == nil {
+		return DefaultSpeedLimits()
+	}
+	return *c.SpeedLimits
+}
+
+// DuplicateRules controls how repeated events are handled. An event with the same
+// sequence number, or without one the same time, event, competitor and parameters as an
+// applied event is an exact duplicate; one that differs only in time is a near duplicate.
+type DuplicateRules struct {
+	// Reject fails exact duplicates with an error instead of ignoring them
+	Reject bool `json:"reject,omitempty"`
+	// Window is the hh:mm:ss.sss interval in which near duplicates are warned about;
+	// 00:00:00.000 disables the warning
+	Window string `json:"window,omitempty"`
+}
+
+// DefaultDuplicateWindow is the near duplicate window when none is configured
+const DefaultDuplicateWindow = time.Second
+
+// DuplicateWindow returns the interval in which near duplicates are warned about
+func (c *Config) DuplicateWindow() time.Duration {
+	if c.Duplicates == nil || c.Duplicates.Window == "" {
+		return DefaultDuplicateWindow
+	}
+	window, _ := parseClockDuration(c.Duplicates.Window)
+	return window
+}
+
+// RejectDuplicates reports whether exact duplicates fail instead of being ignored
+func (c *Config) RejectDuplicates() bool {
+	return c.Duplicates != nil && c.Duplicates.Reject
+}
+
+// parseClockDuration parses a duration in the hh:mm:ss.sss format
+func parseClockDuration(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04:05.000", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)), nil
+}
+
+// Rules represents the effective race rules for a competitor
+type Rules struct {
+	Laps       int
+	LapLen     int
+	PenaltyLen int
+}
+
+// RulesFor returns the rules of the given category, falling back to the race defaults
+func (c *Config) RulesFor(category string) Rules {
+	rules := Rules{Laps: c.Laps, LapLen: c.LapLen, PenaltyLen: c.PenaltyLen}
+	for _, cat := range c.Categories {
+		if cat.Name != category {
+			continue
+		}
+		if cat.Laps > 0 {
+			rules.Laps = cat.Laps
+		}
+		if cat.LapLen > 0 {
+			rules.LapLen = cat.LapLen
+		}
+		if cat.PenaltyLen > 0 {
+			rules.PenaltyLen = cat.PenaltyLen
+		}
+		break
+	}
+	return rules
+}
+
+// CategoryOf returns the category that lists the competitor, or an empty string
+func (c *Config) CategoryOf(competitorID int) string {
+	for _, cat := range c.Categories {
+		for _, id := range cat.Competitors {
+			if id == competitorID {
+				return cat.Name
+			}
+		}
+	}
+	return ""
+}
+
+// CategoryFor returns the ranking group of a competitor: the explicit category of the
+// athlete profile, then the category listing the competitor in the config, then the
+// athlete's gender and age group in the year of the race. The athlete may be nil.
+func (c *Config) CategoryFor(competitorID int, athlete *Athlete) string {
+	if athlete != nil && athlete.Category != "" {
+		return athlete.Category
+	}
+	if category := c.CategoryOf(competitorID); category != "" {
+		return category
+	}
+	if athlete != nil {
+		return athlete.DerivedGroup(c.Year())
+	}
+	return ""
+}
+
+// Year returns the year of the race date, 0 when the date is not set as YYYY-MM-DD
+func (c *Config) Year() int {
+	date, err := time.Parse("2006-01-02", c.Date)
+	if err != nil {
+		return 0
+	}
+	return date.Year()
 }
 
 func (c *Config) GetStartTime() (time.Time, error) {
@@ -36,6 +214,18 @@ func (c *Config) Validate() error {
 		return ErrInvalidFiringLines
 	}
 
+	if err := c.validateCategories(); err != nil {
+		return err
+	}
+	if err := c.validateSpeedLimits(); err != nil {
+		return err
+	}
+	if c.Duplicates != nil && c.Duplicates.Window != "" {
+		if _, err := parseClockDuration(c.Duplicates.Window); err != nil {
+			return fmt.Errorf("invalid duplicate window format: %v", err)
+		}
+	}
+
 	if _, err := c.GetStartTime(); err != nil {
 		return fmt.Errorf("invalid start time format: %v", err)
 	}
@@ -45,3 +235,50 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+func (c *Config) validateCategories() error {
+	names := make(map[string]bool, len(c.Categories))
+	competitors := make(map[int]string)
+	for _, cat := range c.Categories {
+		if cat.Name == "" {
+			return fmt.Errorf("%w: category without a name", ErrInvalidCategory)
+		}
+		if names[cat.Name] {
+			return fmt.Errorf("%w: category %q defined twice", ErrInvalidCategory, cat.Name)
+		}
+		names[cat.Name] = true
+
+		if cat.Laps < 0 || cat.LapLen < 0 || cat.PenaltyLen < 0 {
+			return fmt.Errorf("%w: category %q has negative values", ErrInvalidCategory, cat.Name)
+		}
+		for _, id := range cat.Competitors {
+			if other, exists := competitors[id]; exists {
+				return fmt.Errorf("%w: competitor %d listed in %q and %q", ErrInvalidCategory, id, other, cat.Name)
+			}
+			competitors[id] = cat.Name
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateSpeedLimits() error {
+	if c.SpeedLimits == nil {
+		return nil
+	}
+	segments := []struct {
+		name  string
+		speed SpeedRange
+	}{
+		{"lap", c.SpeedLimits.Lap},
+		{"penalty", c.SpeedLimits.Penalty},
+	}
+	for _, segment := range segments {
+		if segment.speed.Min < 0 || segment.speed.Max < 0 {
+			return fmt.Errorf("%w: negative %s speed", ErrInvalidSpeedLimits, segment.name)
+		}
+		if segment.speed.Max > 0 && segment.speed.Max < segment.speed.Min {
+			return fmt.Errorf("%w: %s maximum below minimum", ErrInvalidSpeedLimits, segment.name)
+		}
+	}
+	return nil
+}
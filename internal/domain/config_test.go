@@ -101,6 +101,58 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "valid speed limits",
+			config: &Config{
+				Laps:        2,
+				LapLen:      3500,
+				PenaltyLen:  150,
+				FiringLines: 2,
+				Start:       "10:00:00.000",
+				StartDelta:  "00:01:30.000",
+				SpeedLimits: &SpeedLimits{Lap: SpeedRange{Min: 2, Max: 10}},
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid duplicate window",
+			config: &Config{
+				Laps:        2,
+				LapLen:      3500,
+				PenaltyLen:  150,
+				FiringLines: 2,
+				Start:       "10:00:00.000",
+				StartDelta:  "00:01:30.000",
+				Duplicates:  &DuplicateRules{Window: "1s"},
+			},
+			expectError: true,
+		},
+		{
+			name: "speed limits maximum below minimum",
+			config: &Config{
+				Laps:        2,
+				LapLen:      3500,
+				PenaltyLen:  150,
+				FiringLines: 2,
+				Start:       "10:00:00.000",
+				StartDelta:  "00:01:30.000",
+				SpeedLimits: &SpeedLimits{Penalty: SpeedRange{Min: 5, Max: 2}},
+			},
+			expectError: true,
+		},
+		{
+			name: "negative speed limit",
+			config: &Config{
+				Laps:        2,
+				LapLen:      3500,
+				PenaltyLen:  150,
+				FiringLines: 2,
+				Start:       "10:00:00.000",
+				StartDelta:  "00:01:30.000",
+				SpeedLimits: &SpeedLimits{Lap: SpeedRange{Min: -1}},
+			},
+			expectError: true,
+		},
 		{
 			name: "invalid laps",
 			config: &Config{
@@ -161,6 +213,77 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "valid categories",
+			config: &Config{
+				Laps:        2,
+				LapLen:      3500,
+				PenaltyLen:  150,
+				FiringLines: 2,
+				Start:       "10:00:00.000",
+				StartDelta:  "00:01:30.000",
+				Categories: []CategoryConfig{
+					{Name: "Men U17", Laps: 3, LapLen: 2000},
+					{Name: "Women U17", Laps: 3, LapLen: 2000, PenaltyLen: 100, Competitors: []int{4, 5}},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "unnamed category",
+			config: &Config{
+				Laps:        2,
+				LapLen:      3500,
+				PenaltyLen:  150,
+				FiringLines: 2,
+				Start:       "10:00:00.000",
+				StartDelta:  "00:01:30.000",
+				Categories:  []CategoryConfig{{Laps: 3}},
+			},
+			expectError: true,
+		},
+		{
+			name: "duplicate category",
+			config: &Config{
+				Laps:        2,
+				LapLen:      3500,
+				PenaltyLen:  150,
+				FiringLines: 2,
+				Start:       "10:00:00.000",
+				StartDelta:  "00:01:30.000",
+				Categories:  []CategoryConfig{{Name: "Men U17"}, {Name: "Men U17"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "negative category lap count",
+			config: &Config{
+				Laps:        2,
+				LapLen:      3500,
+				PenaltyLen:  150,
+				FiringLines: 2,
+				Start:       "10:00:00.000",
+				StartDelta:  "00:01:30.000",
+				Categories:  []CategoryConfig{{Name: "Men U17", Laps: -1}},
+			},
+			expectError: true,
+		},
+		{
+			name: "competitor in two categories",
+			config: &Config{
+				Laps:        2,
+				LapLen:      3500,
+				PenaltyLen:  150,
+				FiringLines: 2,
+				Start:       "10:00:00.000",
+				StartDelta:  "00:01:30.000",
+				Categories: []CategoryConfig{
+					{Name: "Men U17", Competitors: []int{1, 2}},
+					{Name: "Men U19", Competitors: []int{2}},
+				},
+			},
+			expectError: true,
+		},
 		{
 			name: "invalid start delta",
 			config: &Config{
@@ -186,3 +309,79 @@ func TestConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_RulesFor(t *testing.T) {
+	config := &Config{
+		Laps:       2,
+		LapLen:     3500,
+		PenaltyLen: 150,
+		Categories: []CategoryConfig{
+			{Name: "Men U17", Laps: 3, LapLen: 2000},
+			{Name: "Women U17", Laps: 3, LapLen: 2000, PenaltyLen: 100, Competitors: []int{4}},
+		},
+	}
+
+	assert.Equal(t, Rules{Laps: 2, LapLen: 3500, PenaltyLen: 150}, config.RulesFor(""))
+	assert.Equal(t, Rules{Laps: 2, LapLen: 3500, PenaltyLen: 150}, config.RulesFor("Seniors"))
+	assert.Equal(t, Rules{Laps: 3, LapLen: 2000, PenaltyLen: 150}, config.RulesFor("Men U17"))
+	assert.Equal(t, Rules{Laps: 3, LapLen: 2000, PenaltyLen: 100}, config.RulesFor("Women U17"))
+
+	assert.Equal(t, "Women U17", config.CategoryOf(4))
+	assert.Equal(t, "", config.CategoryOf(1))
+}
+
+func TestConfig_CategoryFor(t *testing.T) {
+	config := &Config{Categories: []CategoryConfig{{Name: "Juniors", Competitors: []int{2, 3}}}}
+
+	assert.Equal(t, "Women Senior", config.CategoryFor(2, &Athlete{Gender: "W", Category: "Women Senior"}))
+	assert.Equal(t, "Juniors", config.CategoryFor(2, &Athlete{Gender: "M"}), "the config listing wins over the gender")
+	assert.Equal(t, "Juniors", config.CategoryFor(3, nil))
+	assert.Equal(t, "Women", config.CategoryFor(1, &Athlete{Gender: "W"}))
+	assert.Equal(t, "", config.CategoryFor(1, &Athlete{}))
+	assert.Equal(t, "", config.CategoryFor(1, nil))
+
+	// Without a race date the birth year is not used
+	assert.Equal(t, "Men", config.CategoryFor(1, &Athlete{Gender: "M", BirthYear: 2008}))
+
+	config.Date = "2024-01-13"
+	assert.Equal(t, "Men U17", config.CategoryFor(1, &Athlete{Gender: "M", BirthYear: 2008}))
+	assert.Equal(t, "Juniors", config.CategoryFor(2, &Athlete{Gender: "M", BirthYear: 2008}))
+	assert.Equal(t, "Women Senior", config.CategoryFor(1, &Athlete{Gender: "W", BirthYear: 1998}))
+	assert.Equal(t, "U19", config.CategoryFor(1, &Athlete{BirthYear: 2006}))
+}
+
+func TestConfig_Limits(t *testing.T) {
+	config := &Config{}
+	assert.Equal(t, DefaultSpeedLimits(), config.Limits())
+
+	config.SpeedLimits = &SpeedLimits{Lap: SpeedRange{Max: 9}}
+	assert.Equal(t, SpeedRange{Max: 9}, config.Limits().Lap)
+	assert.Equal(t, SpeedRange{}, config.Limits().Penalty)
+}
+
+func TestConfig_Duplicates(t *testing.T) {
+	config := &Config{}
+	assert.Equal(t, DefaultDuplicateWindow, config.DuplicateWindow())
+	assert.False(t, config.RejectDuplicates())
+
+	config.Duplicates = &DuplicateRules{Reject: true, Window: "00:00:02.500"}
+	assert.Equal(t, 2500*time.Millisecond, config.DuplicateWindow())
+	assert.True(t, config.RejectDuplicates())
+
+	config.Duplicates.Window = "00:00:00.000"
+	assert.Zero(t, config.DuplicateWindow())
+}
+
+func TestSpeedRange(t *testing.T) {
+	r := SpeedRange{Min: 1, Max: 12}
+	assert.True(t, r.Contains(1))
+	assert.True(t, r.Contains(7.5))
+	assert.False(t, r.Contains(0.5))
+	assert.False(t, r.Contains(40))
+	assert.Equal(t, "1.0-12.0 m/s", r.String())
+
+	assert.True(t, SpeedRange{}.Contains(1000))
+	assert.True(t, SpeedRange{Min: 2}.Contains(1000))
+	assert.Equal(t, "at least 2.0 m/s", SpeedRange{Min: 2}.String())
+	assert.Equal(t, "at most 9.0 m/s", SpeedRange{Max: 9}.String())
+}
@@ -7,4 +7,12 @@ var (
 	ErrInvalidLapLen      = errors.New("invalid lap length")
 	ErrInvalidPenaltyLen  = errors.New("invalid penalty length")
 	ErrInvalidFiringLines = errors.New("invalid number of firing lines")
+	ErrInvalidSpeedLimits = errors.New("invalid speed limits")
+	ErrInvalidCategory    = errors.New("invalid category")
+	ErrInvalidAthlete     = errors.New("invalid athlete")
+	ErrDuplicateAthlete   = errors.New("duplicate athlete")
+	ErrInvalidEventFormat = errors.New("invalid event format")
+	ErrUnknownEvent       = errors.New("unknown event")
+	ErrIllegalTransition  = errors.New("illegal state transition")
+	ErrDuplicateEvent     = errors.New("duplicate event")
 )
@@ -1,6 +1,11 @@
 package domain
 
-import "time"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
 
 // EventType represents the type of event (incoming or outgoing)
 type EventType int
@@ -17,6 +22,7 @@ type Event struct {
 	EventID      int
 	CompetitorID int
 	ExtraParams  string
+	Seq          int // sequence number assigned by the timing system, 0 when absent
 }
 
 type IncomingEventID int
@@ -51,3 +57,64 @@ func NewEvent(time time.Time, eventType EventType, eventID int, competitorID int
 		ExtraParams:  extraParams,
 	}
 }
+
+// String formats the event in the `[hh:mm:ss.sss] id competitor extra` format of the events file,
+// prefixed by `#seq` when the event has a sequence number
+func (e *Event) String() string {
+	line := fmt.Sprintf("[%s] %d %d", e.Time.Format("15:04:05.000"), e.EventID, e.CompetitorID)
+	if e.ExtraParams != "" {
+		line += " " + e.ExtraParams
+	}
+	if e.Seq > 0 {
+		line = fmt.Sprintf("#%d %s", e.Seq, line)
+	}
+	return line
+}
+
+// Key identifies the event for duplicate detection: the sequence number when present,
+// otherwise the time, event, competitor and parameters
+func (e *Event) Key() string {
+	if e.Seq > 0 {
+		return fmt.Sprintf("#%d", e.Seq)
+	}
+	return e.String()
+}
+
+var eventRegex = regexp.MustCompile(`(?:#(\d+) )?\[(\d{2}:\d{2}:\d{2}\.\d{3})\] (\d+) (\d+)(?: (.+))?`)
+
+// ParseEvent parses an incoming event from a line in the `[hh:mm:ss.sss] id competitor extra` format,
+// optionally prefixed by a sequence number as `#seq [hh:mm:ss.sss] ...`
+func ParseEvent(line string) (*Event, error) {
+	matches := eventRegex.FindStringSubmatch(line)
+	if len(matches) < 5 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidEventFormat, line)
+	}
+
+	eventTime, err := time.Parse("15:04:05.000", matches[2])
+	if err != nil {
+		return nil, fmt.Errorf("error parsing time: %v", err)
+	}
+
+	eventID, err := strconv.Atoi(matches[3])
+	if err != nil {
+		return nil, fmt.Errorf("error parsing event ID: %v", err)
+	}
+
+	competitorID, err := strconv.Atoi(matches[4])
+	if err != nil {
+		return nil, fmt.Errorf("error parsing competitor ID: %v", err)
+	}
+
+	extraParams := ""
+	if len(matches) > 5 {
+		extraParams = matches[5]
+	}
+
+	event := NewEvent(eventTime, EventTypeIncoming, eventID, competitorID, extraParams)
+	if matches[1] != "" {
+		if event.Seq, err = strconv.Atoi(matches[1]); err != nil {
+			return nil, fmt.Errorf("error parsing sequence number: %v", err)
+		}
+	}
+	return event, nil
+}
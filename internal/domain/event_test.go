@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		name        string
+		line        string
+		expected    *Event
+		expectError error
+	}{
+		{
+			name:     "without extra params",
+			line:     "[09:31:49.285] 1 3",
+			expected: NewEvent(time.Date(0, 1, 1, 9, 31, 49, 285000000, time.UTC), EventTypeIncoming, 1, 3, ""),
+		},
+		{
+			name:     "with extra params",
+			line:     "[10:28:38.151] 11 5 Lost in the forest",
+			expected: NewEvent(time.Date(0, 1, 1, 10, 28, 38, 151000000, time.UTC), EventTypeIncoming, 11, 5, "Lost in the forest"),
+		},
+		{
+			name:     "with a sequence number",
+			line:     "#17 [09:31:49.285] 1 3",
+			expected: &Event{Time: time.Date(0, 1, 1, 9, 31, 49, 285000000, time.UTC), EventID: 1, CompetitorID: 3, Seq: 17},
+		},
+		{
+			name:        "malformed line",
+			line:        "10:28:38 11 5",
+			expectError: ErrInvalidEventFormat,
+		},
+		{
+			name:        "empty line",
+			line:        "",
+			expectError: ErrInvalidEventFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := ParseEvent(tt.line)
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, event)
+		})
+	}
+
+	_, err := ParseEvent("[25:00:00.000] 1 1")
+	assert.Error(t, err)
+}
+
+func TestEvent_String(t *testing.T) {
+	lines := []string{
+		"[09:31:49.285] 1 3",
+		"[09:55:00.000] 2 1 10:00:00.000",
+		"[10:28:38.151] 11 5 Lost in the forest",
+		"#4 [10:28:38.151] 11 5 Lost in the forest",
+	}
+	for _, line := range lines {
+		event, err := ParseEvent(line)
+		assert.NoError(t, err)
+		assert.Equal(t, line, event.String())
+	}
+}
+
+func TestEvent_Key(t *testing.T) {
+	event, _ := ParseEvent("[10:12:35.380] 10 1")
+	assert.Equal(t, "[10:12:35.380] 10 1", event.Key())
+
+	retransmitted, _ := ParseEvent("#8 [10:12:35.390] 10 1")
+	assert.Equal(t, "#8", retransmitted.Key())
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "fmt"
+
 type State int
 
 const (
@@ -14,3 +16,65 @@ const (
 	PenaltyLapLeft
 	LapEnded
 )
+
+var stateNames = map[State]string{
+	Unregistered:       "unregistered",
+	Registered:         "registered",
+	Scheduled:          "scheduled",
+	LapStarted:         "on the start line",
+	Started:            "started",
+	FiringRangeEntered: "on the firing range",
+	FiringRangeLeft:    "left the firing range",
+	PenaltyLapEntered:  "on the penalty laps",
+	PenaltyLapLeft:     "left the penalty laps",
+	LapEnded:           "ended a lap",
+}
+
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// onCourse lists the states in which a competitor is skiing on the main course
+var onCourse = []State{Started, FiringRangeLeft, PenaltyLapLeft, LapEnded}
+
+// transitions lists for every incoming event the states it may be applied in
+var transitions = map[IncomingEventID]struct {
+	from []State
+	to   State
+}{
+	EventRegistered:         {[]State{Unregistered}, Registered},
+	EventStartTimeSet:       {[]State{Registered, Scheduled}, Scheduled},
+	EventOnStartLine:        {[]State{Scheduled}, LapStarted},
+	EventStarted:            {[]State{Scheduled, LapStarted}, Started},
+	EventOnFiringRange:      {onCourse, FiringRangeEntered},
+	EventTargetHit:          {[]State{FiringRangeEntered}, FiringRangeEntered},
+	EventLeftFiringRange:    {[]State{FiringRangeEntered}, FiringRangeLeft},
+	EventEnteredPenaltyLaps: {[]State{FiringRangeLeft}, PenaltyLapEntered},
+	EventLeftPenaltyLaps:    {[]State{PenaltyLapEntered}, PenaltyLapLeft},
+	EventEndedMainLap:       {onCourse, LapEnded},
+}
+
+// Apply returns the state a competitor moves to when the event happens in state s.
+// EventCannotContinue is accepted in every registered state and keeps the state.
+func (s State) Apply(event IncomingEventID) (State, error) {
+	if event == EventCannotContinue {
+		if s == Unregistered {
+			return s, fmt.Errorf("%w: event %d while %s", ErrIllegalTransition, event, s)
+		}
+		return s, nil
+	}
+
+	transition, ok := transitions[event]
+	if !ok {
+		return s, fmt.Errorf("%w: %d", ErrUnknownEvent, event)
+	}
+	for _, from := range transition.from {
+		if from == s {
+			return transition.to, nil
+		}
+	}
+	return s, fmt.Errorf("%w: event %d while %s", ErrIllegalTransition, event, s)
+}
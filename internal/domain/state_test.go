@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState_Apply(t *testing.T) {
+	// The full sequence of a competitor shooting with misses and finishing a lap
+	sequence := []struct {
+		event    IncomingEventID
+		expected State
+	}{
+		{EventRegistered, Registered},
+		{EventStartTimeSet, Scheduled},
+		{EventOnStartLine, LapStarted},
+		{EventStarted, Started},
+		{EventOnFiringRange, FiringRangeEntered},
+		{EventTargetHit, FiringRangeEntered},
+		{EventLeftFiringRange, FiringRangeLeft},
+		{EventEnteredPenaltyLaps, PenaltyLapEntered},
+		{EventLeftPenaltyLaps, PenaltyLapLeft},
+		{EventEndedMainLap, LapEnded},
+		{EventOnFiringRange, FiringRangeEntered},
+		{EventLeftFiringRange, FiringRangeLeft},
+		{EventEndedMainLap, LapEnded},
+		{EventCannotContinue, LapEnded},
+	}
+
+	state := Unregistered
+	for _, step := range sequence {
+		next, err := state.Apply(step.event)
+		assert.NoError(t, err, "event %d in state %s", step.event, state)
+		assert.Equal(t, step.expected, next)
+		state = next
+	}
+}
+
+func TestState_ApplyIllegal(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    State
+		event    IncomingEventID
+		expected error
+	}{
+		{"register twice", Registered, EventRegistered, ErrIllegalTransition},
+		{"start before draw", Registered, EventStarted, ErrIllegalTransition},
+		{"hit outside the range", Started, EventTargetHit, ErrIllegalTransition},
+		{"penalty without shooting", Started, EventEnteredPenaltyLaps, ErrIllegalTransition},
+		{"lap end on the range", FiringRangeEntered, EventEndedMainLap, ErrIllegalTransition},
+		{"lap end in the penalty loop", PenaltyLapEntered, EventEndedMainLap, ErrIllegalTransition},
+		{"give up before registering", Unregistered, EventCannotContinue, ErrIllegalTransition},
+		{"unknown event", Started, IncomingEventID(42), ErrUnknownEvent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := tt.state.Apply(tt.event)
+			assert.ErrorIs(t, err, tt.expected)
+			assert.Equal(t, tt.state, next)
+		})
+	}
+}
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "on the firing range", FiringRangeEntered.String())
+	assert.Equal(t, "State(99)", State(99).String())
+}
@@ -0,0 +1,131 @@
+// Package i18n holds the message catalogs of the event log and the result documents
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// DefaultLanguage is used for messages missing from a catalog
+const DefaultLanguage = "en"
+
+var ErrUnknownLanguage = errors.New("unknown language")
+
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// Catalog maps message keys to fmt format strings. Formats may use explicit argument
+// indexes such as %[2]s to reorder arguments or leave some out.
+type Catalog map[string]string
+
+// aliases maps other language tags to the catalog used for them
+var aliases = map[string]string{
+	"nb": "no",
+	"nn": "no",
+}
+
+var catalogs = mustLoadCatalogs()
+
+func mustLoadCatalogs() map[string]Catalog {
+	entries, err := catalogFiles.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+	catalogs := make(map[string]Catalog, len(entries))
+	for _, entry := range entries {
+		data, err := catalogFiles.ReadFile("catalogs/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		var catalog Catalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("catalog %s: %v", entry.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))] = catalog
+	}
+	return catalogs
+}
+
+// Languages returns the languages with a catalog, sorted
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for language := range catalogs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Lookup returns the catalog of a language
+func Lookup(language string) (Catalog, bool) {
+	catalog, ok := catalogs[language]
+	return catalog, ok
+}
+
+// EventKey returns the message key of the log entry for an event
+func EventKey(eventID int) string {
+	return fmt.Sprintf("event.%d", eventID)
+}
+
+// IncomingEventKey returns the message key of the log entry for an incoming event
+func IncomingEventKey(id domain.IncomingEventID) string {
+	return EventKey(int(id))
+}
+
+// Printer formats messages in one language. Messages missing from its catalog are
+// taken from English. A nil Printer prints English.
+type Printer struct {
+	language string
+	catalog  Catalog
+}
+
+// New returns a printer for a language tag such as "de", "nb" or "ru-RU"
+func New(language string) (*Printer, error) {
+	tag := strings.ToLower(language)
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	if alias, ok := aliases[tag]; ok {
+		tag = alias
+	}
+	catalog, ok := catalogs[tag]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q, expected one of %s", ErrUnknownLanguage, language, strings.Join(Languages(), ", "))
+	}
+	return &Printer{language: tag, catalog: catalog}, nil
+}
+
+// English returns the printer of the default language
+func English() *Printer {
+	return &Printer{language: DefaultLanguage, catalog: catalogs[DefaultLanguage]}
+}
+
+// Language returns the language of the catalog
+func (p *Printer) Language() string {
+	if p == nil {
+		return DefaultLanguage
+	}
+	return p.language
+}
+
+// Sprintf formats the message with the given key; unknown keys are printed as is
+func (p *Printer) Sprintf(key string, args ...interface{}) string {
+	format, ok := "", false
+	if p != nil {
+		format, ok = p.catalog[key]
+	}
+	if !ok {
+		format, ok = catalogs[DefaultLanguage][key]
+	}
+	if !ok {
+		return key
+	}
+	return fmt.Sprintf(format, args...)
+}
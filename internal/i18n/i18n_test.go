@@ -0,0 +1,71 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogs_TranslateEveryEvent(t *testing.T) {
+	require.Contains(t, Languages(), DefaultLanguage)
+	for _, language := range Languages() {
+		catalog, ok := Lookup(language)
+		require.True(t, ok)
+		for id := domain.EventRegistered; id <= domain.EventCannotContinue; id++ {
+			assert.NotEmpty(t, catalog[IncomingEventKey(id)], "%s has no message for event %d", language, id)
+		}
+		assert.NotEmpty(t, catalog[EventKey(int(domain.EventFinished))], "%s has no message for the finish", language)
+	}
+}
+
+func TestCatalogs_MatchEnglish(t *testing.T) {
+	verbs := regexp.MustCompile(`%(\[\d+\])?[.\d]*[a-z]`)
+	english, _ := Lookup(DefaultLanguage)
+	for _, language := range Languages() {
+		catalog, _ := Lookup(language)
+		for key, format := range catalog {
+			reference, ok := english[key]
+			if !assert.True(t, ok, "%s has unknown key %s", language, key) {
+				continue
+			}
+			// Explicit indexes may reorder the arguments, plain verbs must keep them
+			if !regexp.MustCompile(`%\[`).MatchString(reference) {
+				assert.Equal(t, verbs.FindAllString(reference, -1), verbs.FindAllString(format, -1),
+					"%s %s uses other arguments than English", language, key)
+			}
+		}
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	printer, err := New("ru-RU")
+	require.NoError(t, err)
+	assert.Equal(t, "ru", printer.Language())
+	assert.Equal(t, "[10:00:00.000] участник(1) стартовал",
+		printer.Sprintf(IncomingEventKey(domain.EventStarted), "10:00:00.000", printer.Sprintf("competitor", 1), ""))
+
+	printer, err = New("nb")
+	require.NoError(t, err)
+	assert.Equal(t, "no", printer.Language())
+
+	english := English()
+	assert.Equal(t, "[10:00:05.000] The target(3) has been hit by competitor(2)",
+		english.Sprintf(IncomingEventKey(domain.EventTargetHit), "10:00:05.000", english.Sprintf("competitor", 2), "3"))
+
+	_, err = New("xx")
+	assert.ErrorIs(t, err, ErrUnknownLanguage)
+}
+
+func TestPrinter_Fallback(t *testing.T) {
+	printer := &Printer{language: "test", catalog: Catalog{"column.rank": "Platz"}}
+	assert.Equal(t, "Platz", printer.Sprintf("column.rank"))
+	assert.Equal(t, "Lap 2", printer.Sprintf("column.lap", 2), "missing messages are English")
+	assert.Equal(t, "no.such.key", printer.Sprintf("no.such.key"))
+
+	var none *Printer
+	assert.Equal(t, "Rank", none.Sprintf("column.rank"))
+	assert.Equal(t, DefaultLanguage, none.Language())
+}
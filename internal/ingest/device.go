@@ -0,0 +1,100 @@
+package ingest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// Device stands in for a timing device: it sends event lines to a server and waits
+// for the reply to each of them, retransmitting when none arrives in time
+type Device struct {
+	conn    net.Conn
+	replies chan string
+	readErr error // why the replies channel was closed
+	done    chan struct{}
+	Timeout time.Duration // wait for a reply before retransmitting
+	Retries int           // retransmissions before giving up
+}
+
+// Dial connects a device to a server over "tcp" or "udp"
+func Dial(network, address string) (*Device, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	d := &Device{
+		conn:    conn,
+		replies: make(chan string, 16),
+		done:    make(chan struct{}),
+		Timeout: time.Second,
+		Retries: 3,
+	}
+	go d.read()
+	return d, nil
+}
+
+// Send transmits a line and returns the reply of the server. Replies that arrive
+// after their line timed out are discarded before the next line is sent, so they
+// are not taken for the reply to it.
+func (d *Device) Send(line string) (string, error) {
+	if err := d.drain(); err != nil {
+		return "", err
+	}
+	for attempt := 0; attempt <= d.Retries; attempt++ {
+		if _, err := fmt.Fprintln(d.conn, line); err != nil {
+			return "", err
+		}
+		timer := time.NewTimer(d.Timeout)
+		select {
+		case reply, ok := <-d.replies:
+			timer.Stop()
+			if !ok {
+				return "", d.readErr
+			}
+			return reply, nil
+		case <-timer.C:
+		}
+	}
+	return "", fmt.Errorf("no reply after %d attempts", d.Retries+1)
+}
+
+// Close disconnects the device
+func (d *Device) Close() error {
+	close(d.done)
+	return d.conn.Close()
+}
+
+// read passes the replies of the server to Send until the connection is closed
+func (d *Device) read() {
+	scanner := bufio.NewScanner(d.conn)
+	for scanner.Scan() {
+		select {
+		case d.replies <- strings.TrimRight(scanner.Text(), "\r"):
+		case <-d.done:
+			return
+		}
+	}
+	d.readErr = scanner.Err()
+	if d.readErr == nil {
+		d.readErr = io.EOF
+	}
+	close(d.replies)
+}
+
+// drain discards the replies received so far
+func (d *Device) drain() error {
+	for {
+		select {
+		case _, ok := <-d.replies:
+			if !ok {
+				return d.readErr
+			}
+		default:
+			return nil
+		}
+	}
+}
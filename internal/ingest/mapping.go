@@ -0,0 +1,86 @@
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// Rule translates a vendor-specific line into an event. The pattern captures the
+// parts of the event in named groups: time and competitor are required, event and
+// extra are optional and fall back to EventID and Extra.
+//
+// For example a finish-line timer sending `FIN;0012;10:25:26.047` maps with
+// {"pattern": "^FIN;(?P<competitor>\\d+);(?P<time>[\\d:.]+)$", "eventId": 10}.
+type Rule struct {
+	Pattern string `json:"pattern"`
+	EventID int    `json:"eventId,omitempty"`
+	Extra   string `json:"extra,omitempty"`
+	// TimeLayout is the Go layout of the time group, 15:04:05.000 by default
+	TimeLayout string `json:"timeLayout,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+func (r *Rule) compile() error {
+	regex, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("%w: pattern %q: %v", ErrInvalidOptions, r.Pattern, err)
+	}
+	groups := make(map[string]bool)
+	for _, name := range regex.SubexpNames() {
+		groups[name] = true
+	}
+	if !groups["time"] || !groups["competitor"] {
+		return fmt.Errorf("%w: pattern %q needs the time and competitor groups", ErrInvalidOptions, r.Pattern)
+	}
+	if !groups["event"] && r.EventID == 0 {
+		return fmt.Errorf("%w: pattern %q needs an event group or an eventId", ErrInvalidOptions, r.Pattern)
+	}
+	r.regex = regex
+	return nil
+}
+
+// parse returns the event of a matching line, or nil when the rule does not match
+func (r *Rule) parse(line string) (*domain.Event, error) {
+	matches := r.regex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, nil
+	}
+	group := func(name string) string {
+		if index := r.regex.SubexpIndex(name); index >= 0 {
+			return matches[index]
+		}
+		return ""
+	}
+
+	layout := r.TimeLayout
+	if layout == "" {
+		layout = "15:04:05.000"
+	}
+	eventTime, err := time.Parse(layout, group("time"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing time: %v", err)
+	}
+	competitorID, err := strconv.Atoi(group("competitor"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing competitor ID: %v", err)
+	}
+	eventID := r.EventID
+	if value := group("event"); value != "" {
+		if eventID, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("error parsing event ID: %v", err)
+		}
+	}
+	extra := r.Extra
+	if value := group("extra"); value != "" {
+		extra = value
+	}
+
+	// Only the clock time of the event counts, whatever date the layout carries
+	eventTime = time.Date(0, 1, 1, eventTime.Hour(), eventTime.Minute(), eventTime.Second(), eventTime.Nanosecond(), time.UTC)
+	return domain.NewEvent(eventTime, domain.EventTypeIncoming, eventID, competitorID, extra), nil
+}
@@ -0,0 +1,328 @@
+package ingest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+var (
+	ErrInvalidOptions = errors.New("invalid ingest options")
+	ErrMissingRoute   = errors.New("event without a competition ID")
+)
+
+// Replies sent for every received line, followed by the normalized event or the error
+const (
+	ReplyAccepted  = "ACK"
+	ReplyDuplicate = "DUP"
+	ReplyError     = "ERR"
+)
+
+// Options configures the listeners, usually read from a JSON file
+type Options struct {
+	// TCP and UDP are the listen addresses, e.g. ":7000"; an empty address disables the listener
+	TCP string `json:"tcp,omitempty"`
+	UDP string `json:"udp,omitempty"`
+	// Mapping translates vendor-specific lines; lines no rule matches are parsed in the
+	// event file format
+	Mapping []*Rule `json:"mapping,omitempty"`
+	// Dedupe is the number of recently accepted events remembered to detect retransmissions
+	Dedupe int `json:"dedupe"`
+}
+
+// DefaultOptions returns options remembering the last 4096 events
+func DefaultOptions() Options {
+	return Options{Dedupe: 4096}
+}
+
+func (o Options) Validate() error {
+	if o.TCP == "" && o.UDP == "" {
+		return fmt.Errorf("%w: no listen address", ErrInvalidOptions)
+	}
+	if o.Dedupe < 0 {
+		return fmt.Errorf("%w: negative dedupe window", ErrInvalidOptions)
+	}
+	for _, rule := range o.Mapping {
+		if err := rule.compile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats counts the received lines by outcome
+type Stats struct {
+	Accepted   int
+	Duplicates int
+	Rejected   int // unparsable lines and events refused by the sink
+}
+
+// Sink applies an accepted event, usually CompetitionService.ProcessEvent
+type Sink func(event *domain.Event) error
+
+// RoutedSink applies an accepted event to the competition with the given ID, usually
+// Registry.ProcessEvent
+type RoutedSink func(competitionID string, event *domain.Event) error
+
+// Server receives event lines from timing devices over TCP and UDP. Every line is
+// answered with ACK, DUP or ERR so the device knows whether to retransmit. Events are
+// passed to the sink one at a time, in the order they are received.
+type Server struct {
+	opts   Options
+	sink   RoutedSink
+	routed bool // lines are prefixed by the competition ID
+
+	mu    sync.Mutex // serializes the sink and guards the fields below
+	seen  map[string]bool
+	ring  []string // accepted events in arrival order, for evicting from seen
+	next  int
+	stats Stats
+
+	tcp   net.Listener
+	udp   net.PacketConn
+	conns map[net.Conn]bool
+	wg    sync.WaitGroup
+}
+
+// Listen opens the configured listeners and starts serving them
+func Listen(opts Options, sink Sink) (*Server, error) {
+	return listen(opts, func(_ string, event *domain.Event) error {
+		return sink(event)
+	}, false)
+}
+
+// ListenRoutes serves a combined stream of several competitions, each line prefixed by
+// the competition ID like `id [hh:mm:ss.sss] eventID competitorID extra`. The mapping
+// applies to the line after the ID.
+func ListenRoutes(opts Options, sink RoutedSink) (*Server, error) {
+	return listen(opts, sink, true)
+}
+
+func listen(opts Options, sink RoutedSink, routed bool) (*Server, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		opts:   opts,
+		sink:   sink,
+		routed: routed,
+		seen:   make(map[string]bool),
+		ring:   make([]string, opts.Dedupe),
+		conns:  make(map[net.Conn]bool),
+	}
+	if opts.TCP != "" {
+		listener, err := net.Listen("tcp", opts.TCP)
+		if err != nil {
+			return nil, fmt.Errorf("error listening on TCP: %v", err)
+		}
+		s.tcp = listener
+	}
+	if opts.UDP != "" {
+		conn, err := net.ListenPacket("udp", opts.UDP)
+		if err != nil {
+			if s.tcp != nil {
+				s.tcp.Close()
+			}
+			return nil, fmt.Errorf("error listening on UDP: %v", err)
+		}
+		s.udp = conn
+	}
+
+	if s.tcp != nil {
+		s.wg.Add(1)
+		go s.acceptTCP()
+	}
+	if s.udp != nil {
+		s.wg.Add(1)
+		go s.serveUDP()
+	}
+	return s, nil
+}
+
+// TCPAddr returns the address of the TCP listener, nil when it is disabled
+func (s *Server) TCPAddr() net.Addr {
+	if s.tcp == nil {
+		return nil
+	}
+	return s.tcp.Addr()
+}
+
+// UDPAddr returns the address of the UDP listener, nil when it is disabled
+func (s *Server) UDPAddr() net.Addr {
+	if s.udp == nil {
+		return nil
+	}
+	return s.udp.LocalAddr()
+}
+
+// Stats returns the counts of the lines received so far
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Do runs f while no event is applied, so that f can read the state fed by the sink
+func (s *Server) Do(f func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f()
+}
+
+// Close stops the listeners, drops the open connections and waits for the
+// lines being handled
+func (s *Server) Close() error {
+	if s.tcp != nil {
+		s.tcp.Close()
+	}
+	if s.udp != nil {
+		s.udp.Close()
+	}
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Server) acceptTCP() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.serveTCP(conn)
+	}
+}
+
+func (s *Server) serveTCP(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		reply := s.Handle(scanner.Text())
+		if reply == "" {
+			continue
+		}
+		if _, err := fmt.Fprintln(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) serveUDP() {
+	defer s.wg.Done()
+	buffer := make([]byte, 64*1024)
+	for {
+		n, addr, err := s.udp.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+		// A datagram may carry several lines, answered together in one datagram
+		replies := make([]string, 0, 1)
+		for _, line := range strings.Split(string(buffer[:n]), "\n") {
+			if reply := s.Handle(line); reply != "" {
+				replies = append(replies, reply)
+			}
+		}
+		if len(replies) > 0 {
+			s.udp.WriteTo([]byte(strings.Join(replies, "\n")+"\n"), addr)
+		}
+	}
+}
+
+// Handle processes a received line and returns the reply, empty for blank lines.
+// A retransmitted event is acknowledged as a duplicate without reaching the sink.
+func (s *Server) Handle(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return ""
+	}
+
+	id, event, err := s.route(line)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.stats.Rejected++
+		return ReplyError + " " + err.Error()
+	}
+
+	key := event.Key()
+	if s.routed {
+		key = id + " " + key
+	}
+	if s.seen[key] {
+		s.stats.Duplicates++
+		return ReplyDuplicate + " " + key
+	}
+	if err := s.sink(id, event); err != nil {
+		s.stats.Rejected++
+		return ReplyError + " " + err.Error()
+	}
+	s.remember(key)
+	s.stats.Accepted++
+	return ReplyAccepted + " " + key
+}
+
+// remember adds an accepted event to the dedupe window, evicting the oldest one
+func (s *Server) remember(key string) {
+	if len(s.ring) == 0 {
+		return
+	}
+	if old := s.ring[s.next]; old != "" {
+		delete(s.seen, old)
+	}
+	s.ring[s.next] = key
+	s.seen[key] = true
+	s.next = (s.next + 1) % len(s.ring)
+}
+
+// route splits a line of a combined stream into the competition ID and the event.
+// An event without the ID is refused rather than taken for an unparsable line.
+func (s *Server) route(line string) (string, *domain.Event, error) {
+	if !s.routed {
+		event, err := s.parse(line)
+		return "", event, err
+	}
+	id, rest, found := strings.Cut(line, " ")
+	event, err := s.parse(strings.TrimSpace(rest))
+	if found && err == nil {
+		return id, event, nil
+	}
+	if _, lineErr := s.parse(line); lineErr == nil {
+		return "", nil, fmt.Errorf("%w: %q", ErrMissingRoute, line)
+	} else if !found {
+		err = lineErr
+	}
+	return "", nil, err
+}
+
+func (s *Server) parse(line string) (*domain.Event, error) {
+	for _, rule := range s.opts.Mapping {
+		event, err := rule.parse(line)
+		if err != nil || event != nil {
+			return event, err
+		}
+	}
+	return domain.ParseEvent(line)
+}
@@ -0,0 +1,229 @@
+package ingest
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, opts Options) (*Server, *service.CompetitionService) {
+	t.Helper()
+	config := testutil.Config()
+	config.Laps = 2
+	competition := service.NewCompetitionService(config)
+	server, err := Listen(opts, competition.ProcessEvent)
+	require.NoError(t, err)
+	t.Cleanup(func() { server.Close() })
+	return server, competition
+}
+
+func TestServerTCP(t *testing.T) {
+	server, competition := newTestServer(t, Options{TCP: "127.0.0.1:0", Dedupe: 16})
+
+	device, err := Dial("tcp", server.TCPAddr().String())
+	require.NoError(t, err)
+	defer device.Close()
+
+	reply, err := device.Send("[09:00:00.000] 1 1")
+	require.NoError(t, err)
+	assert.Equal(t, "ACK [09:00:00.000] 1 1", reply)
+
+	// A retransmission is acknowledged but not applied twice
+	reply, err = device.Send("[09:00:00.000] 1 1")
+	require.NoError(t, err)
+	assert.Equal(t, "DUP [09:00:00.000] 1 1", reply)
+
+	reply, err = device.Send("garbage")
+	require.NoError(t, err)
+	assert.Contains(t, reply, "ERR invalid event format")
+
+	// Events refused by the competition are reported
+	reply, err = device.Send("[09:00:01.000] 4 2")
+	require.NoError(t, err)
+	assert.Contains(t, reply, "ERR ")
+
+	server.Do(func() {
+		assert.Len(t, competition.GetCompetitors(), 1)
+		assert.Len(t, competition.GetLogEntries(), 1)
+	})
+	assert.Equal(t, Stats{Accepted: 1, Duplicates: 1, Rejected: 2}, server.Stats())
+}
+
+func TestServerUDP(t *testing.T) {
+	server, competition := newTestServer(t, Options{UDP: "127.0.0.1:0", Dedupe: 16})
+
+	device, err := Dial("udp", server.UDPAddr().String())
+	require.NoError(t, err)
+	defer device.Close()
+
+	reply, err := device.Send("[09:00:00.000] 1 3")
+	require.NoError(t, err)
+	assert.Equal(t, "ACK [09:00:00.000] 1 3", reply)
+	reply, err = device.Send("[09:00:00.000] 1 3")
+	require.NoError(t, err)
+	assert.Equal(t, "DUP [09:00:00.000] 1 3", reply)
+	server.Do(func() {
+		assert.Len(t, competition.GetCompetitors(), 1)
+	})
+}
+
+func TestServerMapping(t *testing.T) {
+	mapping := []*Rule{
+		{Pattern: `^FIN;(?P<competitor>\d+);(?P<time>[\d:.]+)$`, EventID: int(domain.EventEndedMainLap)},
+		{Pattern: `^HIT (?P<time>\d{6}\.\d{3}) (?P<competitor>\d+) T(?P<extra>\d)$`, EventID: int(domain.EventTargetHit), TimeLayout: "150405.000"},
+		{Pattern: `^EV(?P<event>\d+)/(?P<competitor>\d+)@(?P<time>[\d:.]+)$`},
+	}
+	opts := Options{TCP: "127.0.0.1:0", Mapping: mapping, Dedupe: 16}
+	server, _ := newTestServer(t, opts)
+
+	tests := []struct {
+		line  string
+		event string
+	}{
+		{"FIN;0012;10:25:26.047", "[10:25:26.047] 10 12"},
+		{"HIT 100849.289 3 T4", "[10:08:49.289] 6 3 4"},
+		{"EV1/7@09:00:00.000", "[09:00:00.000] 1 7"},
+		{"[09:00:00.000] 1 8", "[09:00:00.000] 1 8"},
+	}
+	for _, tt := range tests {
+		event, err := server.parse(tt.line)
+		require.NoError(t, err, tt.line)
+		assert.Equal(t, tt.event, event.String(), tt.line)
+	}
+
+	_, err := server.parse("HIT 109999.000 3 T4")
+	assert.Error(t, err)
+}
+
+func TestServerRoutes(t *testing.T) {
+	registry := service.NewRegistry()
+	for _, id := range []string{"men", "women"} {
+		_, competition := newTestServer(t, Options{TCP: "127.0.0.1:0"})
+		require.NoError(t, registry.Add(id, competition))
+	}
+	server, err := ListenRoutes(Options{TCP: "127.0.0.1:0", Dedupe: 16}, registry.ProcessEvent)
+	require.NoError(t, err)
+	defer server.Close()
+
+	assert.Equal(t, "ACK men [09:00:00.000] 1 1", server.Handle("men [09:00:00.000] 1 1"))
+	// The same event of another race is no retransmission
+	assert.Equal(t, "ACK women [09:00:00.000] 1 1", server.Handle("women [09:00:00.000] 1 1"))
+	assert.Equal(t, "DUP men [09:00:00.000] 1 1", server.Handle("men [09:00:00.000] 1 1"))
+
+	assert.Contains(t, server.Handle("[09:00:00.000] 1 2"), "ERR "+ErrMissingRoute.Error())
+	assert.Contains(t, server.Handle("relay [09:00:00.000] 1 2"), "ERR "+service.ErrUnknownCompetition.Error())
+	assert.Contains(t, server.Handle("men garbage"), "ERR invalid event format")
+	assert.Contains(t, server.Handle("garbage"), "ERR invalid event format")
+
+	for _, id := range []string{"men", "women"} {
+		competition, err := registry.Get(id)
+		require.NoError(t, err)
+		assert.Len(t, competition.GetCompetitors(), 1, id)
+	}
+	assert.Equal(t, Stats{Accepted: 2, Duplicates: 1, Rejected: 4}, server.Stats())
+}
+
+func TestServerDedupeWindow(t *testing.T) {
+	applied := 0
+	server, err := Listen(Options{TCP: "127.0.0.1:0", Dedupe: 2}, func(*domain.Event) error {
+		applied++
+		return nil
+	})
+	require.NoError(t, err)
+	defer server.Close()
+
+	assert.Equal(t, "ACK [09:00:00.000] 1 1", server.Handle("[09:00:00.000] 1 1"))
+	server.Handle("[09:00:00.000] 1 2")
+	server.Handle("[09:00:00.000] 1 3")
+	// The first event fell out of the window and is accepted again
+	assert.Equal(t, "ACK [09:00:00.000] 1 1", server.Handle("[09:00:00.000] 1 1"))
+	assert.Equal(t, "DUP [09:00:00.000] 1 3", server.Handle("[09:00:00.000] 1 3"))
+	assert.Equal(t, 4, applied)
+	assert.Empty(t, server.Handle("  "))
+}
+
+func TestServerSerializesSink(t *testing.T) {
+	// The sink is not synchronized; the race detector flags concurrent calls
+	calls := 0
+	sink := func(*domain.Event) error {
+		calls++
+		return errors.New("refused")
+	}
+	server, err := Listen(Options{TCP: "127.0.0.1:0"}, sink)
+	require.NoError(t, err)
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			device, err := Dial("tcp", server.TCPAddr().String())
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer device.Close()
+			for j := 0; j < 10; j++ {
+				reply, err := device.Send("[09:00:00.000] 1 1")
+				assert.NoError(t, err)
+				assert.Equal(t, "ERR refused", reply)
+			}
+		}()
+	}
+	wg.Wait()
+	server.Do(func() {
+		assert.Equal(t, 40, calls)
+	})
+	assert.Equal(t, 40, server.Stats().Rejected)
+}
+
+func TestDeviceDiscardsLateReplies(t *testing.T) {
+	// The first event is applied after the device retransmitted it
+	slow := true
+	sink := func(*domain.Event) error {
+		if slow {
+			slow = false
+			time.Sleep(120 * time.Millisecond)
+		}
+		return nil
+	}
+	server, err := Listen(Options{TCP: "127.0.0.1:0", Dedupe: 16}, sink)
+	require.NoError(t, err)
+	defer server.Close()
+
+	device, err := Dial("tcp", server.TCPAddr().String())
+	require.NoError(t, err)
+	defer device.Close()
+	device.Timeout = 50 * time.Millisecond
+
+	reply, err := device.Send("[09:00:00.000] 1 1")
+	require.NoError(t, err)
+	assert.Equal(t, "ACK [09:00:00.000] 1 1", reply)
+
+	// The replies to the retransmissions arrive late and are not taken for the next one
+	time.Sleep(50 * time.Millisecond)
+	reply, err = device.Send("[09:00:00.000] 1 2")
+	require.NoError(t, err)
+	assert.Equal(t, "ACK [09:00:00.000] 1 2", reply)
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.ErrorIs(t, DefaultOptions().Validate(), ErrInvalidOptions)
+	assert.ErrorIs(t, Options{TCP: ":0", Dedupe: -1}.Validate(), ErrInvalidOptions)
+
+	for _, rule := range []*Rule{
+		{Pattern: "(", EventID: 1},
+		{Pattern: `^(?P<competitor>\d+)$`, EventID: 1},
+		{Pattern: `^(?P<competitor>\d+) (?P<time>\S+)$`},
+	} {
+		err := Options{TCP: ":0", Mapping: []*Rule{rule}}.Validate()
+		assert.ErrorIs(t, err, ErrInvalidOptions, rule.Pattern)
+	}
+}
@@ -0,0 +1,445 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+var (
+	ErrInvalidOptions = errors.New("invalid notifier options")
+	ErrQueueFull      = errors.New("notification queue full")
+)
+
+// queueSize is the number of deliveries waiting for the worker before new ones are
+// dead-lettered
+const queueSize = 256
+
+// Notification types
+const (
+	TypeFinish           = "finish"
+	TypeDisqualification = "disqualification"
+	TypeLeader           = "leader"
+	TypePodium           = "podium"
+)
+
+// Headers set on every delivery
+const (
+	HeaderEvent     = "X-Biathlon-Event"
+	HeaderSignature = "X-Biathlon-Signature"
+)
+
+// Webhook is a URL receiving notifications
+type Webhook struct {
+	URL string `json:"url"`
+	// Secret signs the body with HMAC-SHA256 when set
+	Secret string `json:"secret,omitempty"`
+	// Events limits the notification types sent to the URL, all of them by default
+	Events []string `json:"events,omitempty"`
+}
+
+// wants reports whether the webhook subscribed to the notification type
+func (w Webhook) wants(kind string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, event := range w.Events {
+		if event == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Options configures the notifier, usually read from a JSON file
+type Options struct {
+	Webhooks []Webhook `json:"webhooks"`
+	// Retries is the number of extra attempts after a failed delivery
+	Retries int `json:"retries"`
+	// Backoff is the wait before the first retry, doubled for every further one
+	Backoff string `json:"backoff,omitempty"`
+	Timeout string `json:"timeout,omitempty"`
+	// DeadLetter is a file collecting the notifications that could not be delivered
+	DeadLetter string `json:"deadLetter,omitempty"`
+}
+
+// DefaultOptions returns three retries starting after one second
+func DefaultOptions() Options {
+	return Options{Retries: 3, Backoff: "1s", Timeout: "10s"}
+}
+
+func (o Options) Validate() error {
+	for _, webhook := range o.Webhooks {
+		if webhook.URL == "" {
+			return fmt.Errorf("%w: webhook without a URL", ErrInvalidOptions)
+		}
+		for _, event := range webhook.Events {
+			switch event {
+			case TypeFinish, TypeDisqualification, TypeLeader, TypePodium:
+			default:
+				return fmt.Errorf("%w: unknown event %q", ErrInvalidOptions, event)
+			}
+		}
+	}
+	if o.Retries < 0 {
+		return fmt.Errorf("%w: negative number of retries", ErrInvalidOptions)
+	}
+	if _, err := parseDuration(o.Backoff); err != nil {
+		return fmt.Errorf("%w: backoff: %v", ErrInvalidOptions, err)
+	}
+	if _, err := parseDuration(o.Timeout); err != nil {
+		return fmt.Errorf("%w: timeout: %v", ErrInvalidOptions, err)
+	}
+	return nil
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err == nil && d < 0 {
+		err = fmt.Errorf("negative duration %s", value)
+	}
+	return d, err
+}
+
+// Competitor describes a competitor in a payload
+type Competitor struct {
+	ID     int    `json:"id"`
+	Bib    int    `json:"bib,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Rank   int    `json:"rank,omitempty"`
+	Time   string `json:"time,omitempty"`
+	Behind string `json:"behind,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Payload is the JSON body posted to the webhooks
+type Payload struct {
+	Type       string       `json:"type"`
+	Time       string       `json:"time"` // race time of the event, hh:mm:ss.sss
+	Category   string       `json:"category,omitempty"`
+	Competitor *Competitor  `json:"competitor,omitempty"`
+	Podium     []Competitor `json:"podium,omitempty"`
+}
+
+type delivery struct {
+	webhook Webhook
+	payload *Payload
+}
+
+// deadLetter is a line of the dead-letter file
+type deadLetter struct {
+	URL      string   `json:"url"`
+	Attempts int      `json:"attempts"`
+	Error    string   `json:"error"`
+	Payload  *Payload `json:"payload"`
+}
+
+// Notifier posts JSON notifications to webhooks. It is registered on a competition
+// as an event handler; deliveries happen in the background in the order of the events.
+// Handlers never wait for a slow webhook: when the queue is full the notification is
+// dead-lettered instead.
+type Notifier struct {
+	opts    Options
+	backoff time.Duration
+	client  *http.Client
+	queue   chan delivery
+	done    chan struct{}
+
+	// sleep is replaced in tests
+	sleep func(time.Duration)
+
+	mu      sync.Mutex
+	leaders map[string]int
+	podiums map[string]string
+	// handled counts the finishes and disqualifications since the last Reset,
+	// delivered is the highest count notified; replays stay quiet up to it
+	handled   int
+	delivered int
+	// skip mutes the events up to skipUntil, see Skip
+	skip      bool
+	skipUntil time.Time
+
+	// deadMu serializes the dead-letter writes of the worker and of send
+	deadMu sync.Mutex
+}
+
+// New validates the options and starts the delivery worker
+func New(opts Options) (*Notifier, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	backoff, _ := parseDuration(opts.Backoff)
+	timeout, _ := parseDuration(opts.Timeout)
+
+	n := &Notifier{
+		opts:    opts,
+		backoff: backoff,
+		client:  &http.Client{Timeout: timeout},
+		queue:   make(chan delivery, queueSize),
+		done:    make(chan struct{}),
+		sleep:   time.Sleep,
+		leaders: make(map[string]int),
+		podiums: make(map[string]string),
+	}
+	go n.run()
+	return n, nil
+}
+
+// Close waits until the queued notifications are delivered or dead-lettered
+func (n *Notifier) Close() {
+	close(n.queue)
+	<-n.done
+}
+
+// Reset forgets the known leaders and podiums, e.g. when a competition is replayed.
+// The events notified before the reset are not notified again when they are replayed.
+func (n *Notifier) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.leaders = make(map[string]int)
+	n.podiums = make(map[string]string)
+	n.handled = 0
+}
+
+// Skip mutes the notifications of events up to the given race time, e.g. the
+// history before the start of a replay. Leaders and podiums are still tracked.
+func (n *Notifier) Skip(until time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.skip, n.skipUntil = true, until
+}
+
+// HandleBefore never rejects events
+func (n *Notifier) HandleBefore(*service.CompetitionService, *domain.Event) error {
+	return nil
+}
+
+// HandleAfter notifies finishes and disqualifications. Ranks only change when a
+// competitor finishes, so leader and podium changes are checked after each finish.
+func (n *Notifier) HandleAfter(s *service.CompetitionService, event *domain.Event) {
+	if event.Type != domain.EventTypeOutgoing {
+		return
+	}
+	var kind string
+	switch domain.OutgoingEventID(event.EventID) {
+	case domain.EventFinished:
+		kind = TypeFinish
+	case domain.EventDisqualified:
+		kind = TypeDisqualification
+	default:
+		return
+	}
+
+	n.mu.Lock()
+	n.handled++
+	muted := n.handled <= n.delivered || n.skip && !event.Time.After(n.skipUntil)
+	if n.handled > n.delivered {
+		n.delivered = n.handled
+	}
+	n.mu.Unlock()
+
+	clock := event.Time.Format("15:04:05.000")
+	if !muted {
+		competitor := n.describe(s, event.CompetitorID)
+		competitor.Reason = event.ExtraParams
+		n.send(&Payload{Type: kind, Time: clock, Category: categoryOf(s, event.CompetitorID), Competitor: competitor})
+	}
+
+	if kind == TypeFinish {
+		for _, group := range s.GetCategoryResults() {
+			n.checkStandings(group, clock, muted)
+		}
+	}
+}
+
+// checkStandings compares the leader and podium of a category with the last known
+// ones. Muted changes are only remembered.
+func (n *Notifier) checkStandings(group service.CategoryResults, clock string, muted bool) {
+	podium := make([]Competitor, 0, 3)
+	key := ""
+	for _, result := range group.Results {
+		if result.Rank == 0 || result.Rank > 3 {
+			break
+		}
+		podium = append(podium, newCompetitor(result))
+		key += fmt.Sprintf("%d:%d,", result.Rank, result.Competitor.ID)
+	}
+	if len(podium) == 0 {
+		return
+	}
+
+	n.mu.Lock()
+	leaderChanged := n.leaders[group.Category] != podium[0].ID
+	podiumChanged := n.podiums[group.Category] != key
+	n.leaders[group.Category] = podium[0].ID
+	n.podiums[group.Category] = key
+	n.mu.Unlock()
+
+	if muted {
+		return
+	}
+	if leaderChanged {
+		leader := podium[0]
+		n.send(&Payload{Type: TypeLeader, Time: clock, Category: group.Category, Competitor: &leader})
+	}
+	if podiumChanged {
+		n.send(&Payload{Type: TypePodium, Time: clock, Category: group.Category, Podium: podium})
+	}
+}
+
+func (n *Notifier) describe(s *service.CompetitionService, competitorID int) *Competitor {
+	for _, result := range s.GetResults() {
+		if result.Competitor.ID == competitorID {
+			competitor := newCompetitor(result)
+			return &competitor
+		}
+	}
+	return &Competitor{ID: competitorID}
+}
+
+func categoryOf(s *service.CompetitionService, competitorID int) string {
+	for _, group := range s.GetCategoryResults() {
+		for _, result := range group.Results {
+			if result.Competitor.ID == competitorID {
+				return group.Category
+			}
+		}
+	}
+	return ""
+}
+
+func newCompetitor(result *service.Result) Competitor {
+	competitor := Competitor{
+		ID:   result.Competitor.ID,
+		Bib:  result.Competitor.ID,
+		Name: result.Competitor.Name(),
+		Rank: result.Rank,
+	}
+	if athlete := result.Competitor.Athlete; athlete != nil {
+		competitor.Bib = athlete.Bib
+	}
+	if result.Rank > 0 {
+		competitor.Time = service.FormatDuration(result.Time)
+		competitor.Behind = "+" + service.FormatDuration(result.Behind)
+	}
+	return competitor
+}
+
+func (n *Notifier) send(payload *Payload) {
+	for _, webhook := range n.opts.Webhooks {
+		if !webhook.wants(payload.Type) {
+			continue
+		}
+		d := delivery{webhook: webhook, payload: payload}
+		select {
+		case n.queue <- d:
+		default:
+			n.deadLetter(d, 0, ErrQueueFull)
+		}
+	}
+}
+
+func (n *Notifier) run() {
+	defer close(n.done)
+	for d := range n.queue {
+		attempts, err := n.deliver(d)
+		if err != nil {
+			n.deadLetter(d, attempts, err)
+		}
+	}
+}
+
+// deliver posts a notification, retrying failed attempts with exponential backoff.
+// Client errors other than 429 Too Many Requests are not retried.
+func (n *Notifier) deliver(d delivery) (int, error) {
+	body, err := json.Marshal(d.payload)
+	if err != nil {
+		return 0, err
+	}
+
+	wait := n.backoff
+	attempt := 0
+	for {
+		attempt++
+		retry, err := n.post(d.webhook, d.payload.Type, body)
+		if err == nil {
+			return attempt, nil
+		}
+		if !retry || attempt > n.opts.Retries {
+			return attempt, err
+		}
+		n.sleep(wait)
+		wait *= 2
+	}
+}
+
+func (n *Notifier) post(webhook Webhook, kind string, body []byte) (bool, error) {
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderEvent, kind)
+	if webhook.Secret != "" {
+		request.Header.Set(HeaderSignature, Sign(webhook.Secret, body))
+	}
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	response.Body.Close()
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	retry := response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("unexpected status %s", response.Status)
+}
+
+// deadLetter appends an undeliverable notification to the dead-letter file as a JSON line
+func (n *Notifier) deadLetter(d delivery, attempts int, err error) {
+	if n.opts.DeadLetter == "" {
+		return
+	}
+	line, marshalErr := json.Marshal(deadLetter{
+		URL:      d.webhook.URL,
+		Attempts: attempts,
+		Error:    err.Error(),
+		Payload:  d.payload,
+	})
+	if marshalErr != nil {
+		return
+	}
+
+	n.deadMu.Lock()
+	defer n.deadMu.Unlock()
+	file, openErr := os.OpenFile(n.opts.DeadLetter, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if openErr != nil {
+		fmt.Fprintf(os.Stderr, "notify: cannot write dead letter: %v\n", openErr)
+		return
+	}
+	defer file.Close()
+	file.Write(append(line, '\n'))
+}
+
+// Sign returns the signature header value of a body: "sha256=" and the hex HMAC-SHA256
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,291 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver is a webhook endpoint answering with the queued status codes, then 200
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (r *receiver) payloads(t *testing.T) []Payload {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	payloads := make([]Payload, 0, len(r.bodies))
+	for _, body := range r.bodies {
+		var payload Payload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		payloads = append(payloads, payload)
+	}
+	return payloads
+}
+
+func newTestCompetition() *service.CompetitionService {
+	return service.NewCompetitionService(testutil.Config())
+}
+
+func race(t *testing.T, competition *service.CompetitionService, competitorID int, start, finish string) {
+	t.Helper()
+	for _, event := range []*domain.Event{
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, competitorID, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, competitorID, start),
+		testutil.Incoming(start, domain.EventStarted, competitorID, ""),
+		testutil.Incoming(finish, domain.EventEndedMainLap, competitorID, ""),
+	} {
+		require.NoError(t, competition.ProcessEvent(event))
+	}
+}
+
+func newTestNotifier(t *testing.T, opts Options) (*Notifier, *[]time.Duration) {
+	t.Helper()
+	notifier, err := New(opts)
+	require.NoError(t, err)
+	sleeps := make([]time.Duration, 0)
+	notifier.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	return notifier, &sleeps
+}
+
+func TestNotifier_FinishesAndStandings(t *testing.T) {
+	endpoint := &receiver{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	notifier, _ := newTestNotifier(t, Options{Webhooks: []Webhook{{URL: server.URL}}})
+	competition := newTestCompetition()
+	competition.AddHandler(notifier)
+
+	race(t, competition, 2, "10:01:00.000", "10:13:00.000")
+	race(t, competition, 1, "10:00:00.000", "10:11:00.000")
+	race(t, competition, 3, "10:02:00.000", "10:20:00.000")
+	require.NoError(t, competition.ProcessEvent(testutil.Incoming("10:20:30.000", domain.EventRegistered, 4, "")))
+	require.NoError(t, competition.ProcessEvent(testutil.Incoming("10:21:00.000", domain.EventCannotContinue, 4, "Broken ski")))
+	notifier.Close()
+
+	payloads := endpoint.payloads(t)
+	types := make([]string, 0, len(payloads))
+	for _, payload := range payloads {
+		types = append(types, payload.Type)
+	}
+	assert.Equal(t, []string{
+		TypeFinish, TypeLeader, TypePodium, // 2 leads alone
+		TypeFinish, TypeLeader, TypePodium, // 1 takes the lead
+		TypeFinish, TypePodium, // 3 is third
+		TypeDisqualification,
+	}, types)
+
+	assert.Equal(t, &Competitor{ID: 2, Bib: 2, Rank: 1, Time: "00:12:00.000", Behind: "+00:00:00.000"}, payloads[0].Competitor)
+	assert.Equal(t, "10:11:00.000", payloads[4].Time)
+	assert.Equal(t, 1, payloads[4].Competitor.ID)
+	require.Len(t, payloads[7].Podium, 3)
+	assert.Equal(t, []int{1, 2, 3}, []int{payloads[7].Podium[0].ID, payloads[7].Podium[1].ID, payloads[7].Podium[2].ID})
+	assert.Equal(t, "Broken ski", payloads[8].Competitor.Reason)
+
+	assert.Equal(t, "application/json", endpoint.requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, TypeFinish, endpoint.requests[0].Header.Get(HeaderEvent))
+	assert.Empty(t, endpoint.requests[0].Header.Get(HeaderSignature))
+}
+
+func TestNotifier_SignatureAndFilter(t *testing.T) {
+	endpoint := &receiver{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	notifier, _ := newTestNotifier(t, Options{Webhooks: []Webhook{
+		{URL: server.URL, Secret: "s3cret", Events: []string{TypeLeader}},
+	}})
+	competition := newTestCompetition()
+	competition.AddHandler(notifier)
+	race(t, competition, 1, "10:00:00.000", "10:11:00.000")
+	notifier.Close()
+
+	require.Len(t, endpoint.bodies, 1)
+	assert.Equal(t, TypeLeader, endpoint.requests[0].Header.Get(HeaderEvent))
+	signature := endpoint.requests[0].Header.Get(HeaderSignature)
+	assert.True(t, strings.HasPrefix(signature, "sha256="))
+	assert.Equal(t, Sign("s3cret", endpoint.bodies[0]), signature)
+	assert.NotEqual(t, Sign("other", endpoint.bodies[0]), signature)
+}
+
+func TestNotifier_Retries(t *testing.T) {
+	endpoint := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	notifier, sleeps := newTestNotifier(t, Options{
+		Webhooks: []Webhook{{URL: server.URL, Events: []string{TypeFinish}}},
+		Retries:  3,
+		Backoff:  "100ms",
+	})
+	competition := newTestCompetition()
+	competition.AddHandler(notifier)
+	race(t, competition, 1, "10:00:00.000", "10:11:00.000")
+	notifier.Close()
+
+	assert.Len(t, endpoint.requests, 3)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, *sleeps)
+}
+
+func TestNotifier_DeadLetter(t *testing.T) {
+	endpoint := &receiver{statuses: []int{500, 500, 500, http.StatusBadRequest}}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	deadLetters := filepath.Join(t.TempDir(), "dead.jsonl")
+	notifier, sleeps := newTestNotifier(t, Options{
+		Webhooks:   []Webhook{{URL: server.URL, Events: []string{TypeFinish}}},
+		Retries:    2,
+		Backoff:    "1s",
+		DeadLetter: deadLetters,
+	})
+	competition := newTestCompetition()
+	competition.AddHandler(notifier)
+	race(t, competition, 1, "10:00:00.000", "10:11:00.000")
+	race(t, competition, 2, "10:01:00.000", "10:12:00.000")
+	notifier.Close()
+
+	// Three failed attempts for the first finish, a single one for the rejected second
+	assert.Len(t, endpoint.requests, 4)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *sleeps)
+
+	data, err := os.ReadFile(deadLetters)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var first, second deadLetter
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, server.URL, first.URL)
+	assert.Equal(t, 3, first.Attempts)
+	assert.Equal(t, "unexpected status 500 Internal Server Error", first.Error)
+	assert.Equal(t, 1, first.Payload.Competitor.ID)
+	assert.Equal(t, 1, second.Attempts)
+	assert.Equal(t, 2, second.Payload.Competitor.ID)
+}
+
+func TestNotifier_QueueFull(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	deadLetters := filepath.Join(t.TempDir(), "dead.jsonl")
+	notifier, _ := newTestNotifier(t, Options{Webhooks: []Webhook{{URL: server.URL}}, DeadLetter: deadLetters})
+
+	// The webhook hangs, so the worker holds at most one delivery besides the queue
+	sent := queueSize + 10
+	for i := 0; i < sent; i++ {
+		notifier.send(&Payload{Type: TypeFinish, Competitor: &Competitor{ID: i + 1}})
+	}
+	close(release)
+	notifier.Close()
+
+	data, err := os.ReadFile(deadLetters)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.GreaterOrEqual(t, len(lines), sent-queueSize-1)
+
+	var last deadLetter
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &last))
+	assert.Equal(t, 0, last.Attempts)
+	assert.Equal(t, ErrQueueFull.Error(), last.Error)
+	assert.Equal(t, sent, last.Payload.Competitor.ID)
+}
+
+func TestNotifier_Reset(t *testing.T) {
+	endpoint := &receiver{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	notifier, _ := newTestNotifier(t, Options{Webhooks: []Webhook{{URL: server.URL, Events: []string{TypeLeader}}}})
+	competition := newTestCompetition()
+	competition.AddHandler(notifier)
+	race(t, competition, 1, "10:00:00.000", "10:11:00.000")
+
+	competition.Reset()
+	notifier.Reset()
+	race(t, competition, 1, "10:00:00.000", "10:11:00.000")
+	race(t, competition, 2, "10:01:00.000", "10:10:00.000")
+	notifier.Close()
+
+	// The replayed finish was notified before the reset, the new leader was not
+	payloads := endpoint.payloads(t)
+	require.Len(t, payloads, 2)
+	assert.Equal(t, 1, payloads[0].Competitor.ID)
+	assert.Equal(t, 2, payloads[1].Competitor.ID)
+}
+
+func TestNotifier_Skip(t *testing.T) {
+	endpoint := &receiver{}
+	server := httptest.NewServer(endpoint)
+	defer server.Close()
+
+	notifier, _ := newTestNotifier(t, Options{Webhooks: []Webhook{{URL: server.URL}}})
+	notifier.Skip(testutil.Incoming("10:12:00.000", 0, 0, "").Time)
+	competition := newTestCompetition()
+	competition.AddHandler(notifier)
+	race(t, competition, 1, "10:00:00.000", "10:11:00.000")
+	race(t, competition, 2, "10:01:00.000", "10:13:00.000")
+	notifier.Close()
+
+	// Competitor 1 still leads, so only the finish of 2 and the new podium are sent
+	payloads := endpoint.payloads(t)
+	require.Len(t, payloads, 2)
+	assert.Equal(t, TypeFinish, payloads[0].Type)
+	assert.Equal(t, 2, payloads[0].Competitor.ID)
+	assert.Equal(t, TypePodium, payloads[1].Type)
+	assert.Len(t, payloads[1].Podium, 2)
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"missing URL", Options{Webhooks: []Webhook{{}}}},
+		{"unknown event", Options{Webhooks: []Webhook{{URL: "http://localhost", Events: []string{"lap"}}}}},
+		{"negative retries", Options{Retries: -1}},
+		{"invalid backoff", Options{Backoff: "soon"}},
+		{"negative timeout", Options{Timeout: "-1s"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.opts.Validate(), ErrInvalidOptions)
+		})
+	}
+	assert.NoError(t, DefaultOptions().Validate())
+}
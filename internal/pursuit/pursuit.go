@@ -0,0 +1,169 @@
+package pursuit
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+var (
+	ErrInvalidOptions = errors.New("invalid pursuit options")
+	ErrNoResults      = errors.New("no finishers to start the pursuit")
+)
+
+// Options controls how the start list is derived from the previous race
+type Options struct {
+	Top        int           // number of qualified competitors, all finishers when 0
+	Category   string        // only competitors of this category, all of them when empty
+	Start      time.Time     // start time of the winner of the previous race
+	WaveCutoff time.Duration // competitors further behind start together at Start+WaveCutoff, 0 disables the wave
+	Round      time.Duration // gaps are truncated to this resolution, 0 keeps them exact
+}
+
+func (o Options) Validate() error {
+	if o.Top < 0 {
+		return fmt.Errorf("%w: negative number of competitors", ErrInvalidOptions)
+	}
+	if o.WaveCutoff < 0 || o.Round < 0 {
+		return fmt.Errorf("%w: negative duration", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// Entry is a line of the pursuit start list
+type Entry struct {
+	Position     int // rank in the previous race
+	CompetitorID int
+	AthleteID    string
+	Bib          int
+	Name         string
+	Gap          time.Duration // behind the winner of the previous race
+	Start        time.Time
+	Wave         bool // starts in the wave instead of with the gap
+}
+
+// StartList is the ordered list of pursuit starters
+type StartList struct {
+	Entries []Entry
+}
+
+// Generate selects the qualified finishers of a previous race and staggers their
+// starts by their time behind the winner. Competitors tied with the last qualified
+// one qualify as well.
+func Generate(results *service.ResultsExport, opts Options) (*StartList, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	type finisher struct {
+		result service.ExportedResult
+		rank   int
+		time   time.Duration
+	}
+	finishers := make([]finisher, 0, len(results.Results))
+	for _, result := range results.Results {
+		rank := result.Rank
+		if opts.Category != "" {
+			if result.Category != opts.Category {
+				continue
+			}
+			rank = result.CategoryRank
+		}
+		if rank == 0 {
+			continue
+		}
+		raceTime, err := service.ParseDuration(result.Time)
+		if err != nil {
+			return nil, fmt.Errorf("competitor %d: %v", result.CompetitorID, err)
+		}
+		finishers = append(finishers, finisher{result: result, rank: rank, time: raceTime})
+	}
+	if len(finishers) == 0 {
+		return nil, ErrNoResults
+	}
+	sort.SliceStable(finishers, func(i, j int) bool {
+		if finishers[i].time != finishers[j].time {
+			return finishers[i].time < finishers[j].time
+		}
+		return finishers[i].result.CompetitorID < finishers[j].result.CompetitorID
+	})
+
+	if opts.Top > 0 && len(finishers) > opts.Top {
+		cut := opts.Top
+		for cut < len(finishers) && finishers[cut].time == finishers[opts.Top-1].time {
+			cut++
+		}
+		finishers = finishers[:cut]
+	}
+
+	list := &StartList{Entries: make([]Entry, 0, len(finishers))}
+	winner := finishers[0].time
+	for _, f := range finishers {
+		gap := f.time - winner
+		if opts.Round > 0 {
+			gap = gap.Truncate(opts.Round)
+		}
+		entry := Entry{
+			Position:     f.rank,
+			CompetitorID: f.result.CompetitorID,
+			AthleteID:    f.result.AthleteID,
+			Bib:          f.result.Bib,
+			Name:         f.result.Name,
+			Gap:          gap,
+			Start:        opts.Start.Add(gap),
+		}
+		if opts.WaveCutoff > 0 && gap > opts.WaveCutoff {
+			entry.Wave = true
+			entry.Start = opts.Start.Add(opts.WaveCutoff)
+		}
+		list.Entries = append(list.Entries, entry)
+	}
+	return list, nil
+}
+
+// Events returns the registration and start time draw of every starter at the given
+// time, in start list order, ready to be prepended to the pursuit events file
+func (l *StartList) Events(at time.Time) []*domain.Event {
+	events := make([]*domain.Event, 0, 2*len(l.Entries))
+	for _, entry := range l.Entries {
+		events = append(events, domain.NewEvent(at, domain.EventTypeIncoming, int(domain.EventRegistered), entry.CompetitorID, ""))
+	}
+	for _, entry := range l.Entries {
+		events = append(events, domain.NewEvent(at, domain.EventTypeIncoming, int(domain.EventStartTimeSet),
+			entry.CompetitorID, entry.Start.Format("15:04:05.000")))
+	}
+	return events
+}
+
+// Config returns a copy of the pursuit race config starting with the winner.
+// Starts are individual, so the start interval is zero.
+func (l *StartList) Config(template *domain.Config) *domain.Config {
+	config := *template
+	if len(l.Entries) > 0 {
+		config.Start = l.Entries[0].Start.Format("15:04:05.000")
+	}
+	config.StartDelta = "00:00:00.000"
+	return &config
+}
+
+// String renders the start list one starter per line
+func (l *StartList) String() string {
+	var b strings.Builder
+	for _, entry := range l.Entries {
+		line := fmt.Sprintf("%d. %d", entry.Position, entry.Bib)
+		if entry.Name != "" {
+			line += " " + entry.Name
+		}
+		line += fmt.Sprintf(" %s +%s", entry.Start.Format("15:04:05.000"), service.FormatDuration(entry.Gap))
+		if entry.Wave {
+			line += " wave"
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package pursuit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sprintResults() *service.ResultsExport {
+	return &service.ResultsExport{
+		Name: "Sprint",
+		Results: []service.ExportedResult{
+			{Rank: 1, CategoryRank: 1, CompetitorID: 7, Bib: 17, Name: "Jonas Keller", Category: "Men", Status: "Finished", Time: "00:24:10.000"},
+			{Rank: 2, CategoryRank: 1, CompetitorID: 3, Bib: 13, Name: "Ingrid Solberg", Category: "Women", Status: "Finished", Time: "00:24:35.400"},
+			{Rank: 3, CategoryRank: 2, CompetitorID: 5, Bib: 15, Category: "Men", Status: "Finished", Time: "00:25:40.000"},
+			{Rank: 3, CategoryRank: 2, CompetitorID: 4, Bib: 14, Category: "Women", Status: "Finished", Time: "00:25:40.000"},
+			{Rank: 5, CategoryRank: 3, CompetitorID: 9, Bib: 19, Category: "Men", Status: "Finished", Time: "00:29:00.000"},
+			{CompetitorID: 2, Bib: 12, Category: "Men", Status: "NotFinished"},
+		},
+	}
+}
+
+func clock(value string) time.Time {
+	t, _ := time.Parse("15:04:05.000", value)
+	return t
+}
+
+func TestGenerate(t *testing.T) {
+	list, err := Generate(sprintResults(), Options{Start: clock("12:00:00.000")})
+	require.NoError(t, err)
+	require.Len(t, list.Entries, 5)
+
+	assert.Equal(t, 7, list.Entries[0].CompetitorID)
+	assert.Equal(t, clock("12:00:00.000"), list.Entries[0].Start)
+	assert.Equal(t, 25400*time.Millisecond, list.Entries[1].Gap)
+	assert.Equal(t, clock("12:00:25.400"), list.Entries[1].Start)
+	// Tied competitors start together, the lower ID first
+	assert.Equal(t, 4, list.Entries[2].CompetitorID)
+	assert.Equal(t, 5, list.Entries[3].CompetitorID)
+	assert.Equal(t, list.Entries[2].Start, list.Entries[3].Start)
+	assert.Equal(t, clock("12:04:50.000"), list.Entries[4].Start)
+}
+
+func TestGenerateTopIncludesTies(t *testing.T) {
+	list, err := Generate(sprintResults(), Options{Top: 3, Start: clock("12:00:00.000")})
+	require.NoError(t, err)
+	assert.Len(t, list.Entries, 4)
+
+	list, err = Generate(sprintResults(), Options{Top: 2, Start: clock("12:00:00.000")})
+	require.NoError(t, err)
+	assert.Len(t, list.Entries, 2)
+}
+
+func TestGenerateWave(t *testing.T) {
+	list, err := Generate(sprintResults(), Options{Start: clock("12:00:00.000"), WaveCutoff: 2 * time.Minute})
+	require.NoError(t, err)
+
+	last := list.Entries[4]
+	assert.True(t, last.Wave)
+	assert.Equal(t, 4*time.Minute+50*time.Second, last.Gap)
+	assert.Equal(t, clock("12:02:00.000"), last.Start)
+	assert.False(t, list.Entries[1].Wave)
+}
+
+func TestGenerateCategoryAndRound(t *testing.T) {
+	list, err := Generate(sprintResults(), Options{Category: "Women", Start: clock("12:00:00.000"), Round: time.Second})
+	require.NoError(t, err)
+	require.Len(t, list.Entries, 2)
+
+	assert.Equal(t, 3, list.Entries[0].CompetitorID)
+	assert.Equal(t, 1, list.Entries[0].Position)
+	assert.Equal(t, 2, list.Entries[1].Position)
+	// 01:04.6 behind is truncated to whole seconds
+	assert.Equal(t, 64*time.Second, list.Entries[1].Gap)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, err := Generate(sprintResults(), Options{Top: -1})
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+
+	_, err = Generate(sprintResults(), Options{Category: "Juniors"})
+	assert.ErrorIs(t, err, ErrNoResults)
+
+	results := sprintResults()
+	results.Results[0].Time = "24:10"
+	_, err = Generate(results, Options{})
+	assert.Error(t, err)
+}
+
+func TestStartListEventsAndConfig(t *testing.T) {
+	list, err := Generate(sprintResults(), Options{Top: 2, Start: clock("12:00:00.000")})
+	require.NoError(t, err)
+
+	events := list.Events(clock("11:30:00.000"))
+	require.Len(t, events, 4)
+	assert.Equal(t, "[11:30:00.000] 1 7", events[0].String())
+	assert.Equal(t, "[11:30:00.000] 1 3", events[1].String())
+	assert.Equal(t, "[11:30:00.000] 2 7 12:00:00.000", events[2].String())
+	assert.Equal(t, "[11:30:00.000] 2 3 12:00:25.400", events[3].String())
+
+	template := &domain.Config{Name: "Pursuit", Laps: 5, Start: "10:00:00.000", StartDelta: "00:00:30.000"}
+	config := list.Config(template)
+	assert.Equal(t, "12:00:00.000", config.Start)
+	assert.Equal(t, "00:00:00.000", config.StartDelta)
+	assert.Equal(t, 5, config.Laps)
+	assert.Equal(t, "00:00:30.000", template.StartDelta)
+
+	assert.Equal(t, "1. 17 Jonas Keller 12:00:00.000 +00:00:00.000\n2. 13 Ingrid Solberg 12:00:25.400 +00:00:25.400\n", list.String())
+}
@@ -0,0 +1,273 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+var ErrInvalidSpeed = errors.New("invalid replay speed")
+
+// Instant replays all events without waiting
+const Instant = 0
+
+// Sink receives the replayed events, typically CompetitionService.ProcessEvent
+type Sink func(event *domain.Event) error
+
+// Status is a snapshot of the player state
+type Status struct {
+	Position int       // number of events applied
+	Total    int       // number of events in the recording
+	Clock    time.Time // race time reached by the replay
+	Speed    float64
+	Paused   bool
+}
+
+type commandKind int
+
+const (
+	commandPause commandKind = iota
+	commandResume
+	commandToggle
+	commandSeek
+	commandSpeed
+)
+
+type command struct {
+	kind  commandKind
+	at    time.Time
+	speed float64
+}
+
+// Player feeds a recorded event stream into a sink, honouring the gaps between
+// event timestamps scaled by the playback speed
+type Player struct {
+	events   []*domain.Event
+	sink     Sink
+	reset    func()
+	commands chan command
+	stopped  chan struct{} // closed when Run returns
+
+	// now and after are replaced in tests
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+
+	mu     sync.Mutex
+	pos    int
+	clock  time.Time
+	speed  float64
+	paused bool
+}
+
+// NewPlayer creates a player for chronologically ordered events. Seeking backwards
+// calls reset and replays the events from the beginning, so reset must restore the
+// sink to its initial state.
+func NewPlayer(events []*domain.Event, sink Sink, reset func(), speed float64) (*Player, error) {
+	if speed < 0 {
+		return nil, ErrInvalidSpeed
+	}
+	p := &Player{
+		events:   events,
+		sink:     sink,
+		reset:    reset,
+		commands: make(chan command, 16),
+		stopped:  make(chan struct{}),
+		now:      time.Now,
+		after:    time.After,
+		speed:    speed,
+	}
+	if len(events) > 0 {
+		p.clock = events[0].Time
+	}
+	return p, nil
+}
+
+// Run plays the events until all of them are applied, the sink fails or ctx is done.
+// Commands given after Run returned are ignored.
+func (p *Player) Run(ctx context.Context) error {
+	defer close(p.stopped)
+	for {
+		p.mu.Lock()
+		if p.pos >= len(p.events) {
+			p.mu.Unlock()
+			return nil
+		}
+		next := p.events[p.pos]
+		paused, speed := p.paused, p.speed
+		wait := time.Duration(0)
+		if speed != Instant && next.Time.After(p.clock) {
+			wait = time.Duration(float64(next.Time.Sub(p.clock)) / speed)
+		}
+		p.mu.Unlock()
+
+		if !paused && wait == 0 {
+			if err := p.applyNext(); err != nil {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case cmd := <-p.commands:
+				if err := p.handle(cmd); err != nil {
+					return err
+				}
+			default:
+			}
+			continue
+		}
+
+		var timer <-chan time.Time
+		if !paused {
+			timer = p.after(wait)
+		}
+		started := p.now()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer:
+			if err := p.applyNext(); err != nil {
+				return err
+			}
+		case cmd := <-p.commands:
+			if !paused {
+				p.advance(p.now().Sub(started), next.Time)
+			}
+			if err := p.handle(cmd); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Pause stops the replay until Resume is called
+func (p *Player) Pause() { p.send(command{kind: commandPause}) }
+
+// Resume continues a paused replay
+func (p *Player) Resume() { p.send(command{kind: commandResume}) }
+
+// TogglePause pauses a running replay or resumes a paused one
+func (p *Player) TogglePause() { p.send(command{kind: commandToggle}) }
+
+// Seek moves the replay to the given race time. Events up to that time are applied
+// immediately; seeking backwards resets the sink and replays from the beginning.
+func (p *Player) Seek(at time.Time) { p.send(command{kind: commandSeek, at: at}) }
+
+// SeekBy moves the replay relative to the current race time
+func (p *Player) SeekBy(d time.Duration) {
+	p.Seek(p.Status().Clock.Add(d))
+}
+
+// SetSpeed changes the playback speed; Instant plays the remaining events at once
+func (p *Player) SetSpeed(speed float64) error {
+	if speed < 0 {
+		return ErrInvalidSpeed
+	}
+	p.send(command{kind: commandSpeed, speed: speed})
+	return nil
+}
+
+// send queues a command for Run, dropping it once Run has returned
+func (p *Player) send(cmd command) {
+	select {
+	case p.commands <- cmd:
+	case <-p.stopped:
+	}
+}
+
+// Status returns the current state of the player
+func (p *Player) Status() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Status{
+		Position: p.pos,
+		Total:    len(p.events),
+		Clock:    p.clock,
+		Speed:    p.speed,
+		Paused:   p.paused,
+	}
+}
+
+func (p *Player) handle(cmd command) error {
+	switch cmd.kind {
+	case commandPause:
+		p.setPaused(true)
+	case commandResume:
+		p.setPaused(false)
+	case commandToggle:
+		p.mu.Lock()
+		p.paused = !p.paused
+		p.mu.Unlock()
+	case commandSpeed:
+		p.mu.Lock()
+		p.speed = cmd.speed
+		p.mu.Unlock()
+	case commandSeek:
+		return p.seek(cmd.at)
+	}
+	return nil
+}
+
+func (p *Player) setPaused(paused bool) {
+	p.mu.Lock()
+	p.paused = paused
+	p.mu.Unlock()
+}
+
+func (p *Player) seek(at time.Time) error {
+	p.mu.Lock()
+	backwards := at.Before(p.clock)
+	p.mu.Unlock()
+
+	if backwards {
+		if p.reset != nil {
+			p.reset()
+		}
+		p.mu.Lock()
+		p.pos = 0
+		p.mu.Unlock()
+	}
+
+	for {
+		p.mu.Lock()
+		done := p.pos >= len(p.events) || p.events[p.pos].Time.After(at)
+		p.mu.Unlock()
+		if done {
+			break
+		}
+		if err := p.applyNext(); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	p.clock = at
+	p.mu.Unlock()
+	return nil
+}
+
+// advance moves the race clock by the wall time spent waiting, without passing the next event
+func (p *Player) advance(elapsed time.Duration, limit time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.speed == Instant {
+		return
+	}
+	p.clock = p.clock.Add(time.Duration(float64(elapsed) * p.speed))
+	if p.clock.After(limit) {
+		p.clock = limit
+	}
+}
+
+func (p *Player) applyNext() error {
+	p.mu.Lock()
+	event := p.events[p.pos]
+	p.pos++
+	if event.Time.After(p.clock) {
+		p.clock = event.Time
+	}
+	p.mu.Unlock()
+	return p.sink(event)
+}
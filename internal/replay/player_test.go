@@ -0,0 +1,146 @@
+package replay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func at(clock string) time.Time {
+	t, _ := time.Parse("15:04:05.000", clock)
+	return t
+}
+
+func testEvents() []*domain.Event {
+	return []*domain.Event{
+		domain.NewEvent(at("10:00:00.000"), domain.EventTypeIncoming, int(domain.EventRegistered), 1, ""),
+		domain.NewEvent(at("10:00:10.000"), domain.EventTypeIncoming, int(domain.EventRegistered), 2, ""),
+		domain.NewEvent(at("10:00:10.000"), domain.EventTypeIncoming, int(domain.EventRegistered), 3, ""),
+		domain.NewEvent(at("10:01:00.000"), domain.EventTypeIncoming, int(domain.EventRegistered), 4, ""),
+	}
+}
+
+// recorder collects the competitor IDs of the replayed events
+type recorder struct {
+	ids []int
+}
+
+func (r *recorder) sink(event *domain.Event) error {
+	r.ids = append(r.ids, event.CompetitorID)
+	return nil
+}
+
+func (r *recorder) reset() {
+	r.ids = nil
+}
+
+func TestPlayer_ScaledGaps(t *testing.T) {
+	rec := &recorder{}
+	player, err := NewPlayer(testEvents(), rec.sink, rec.reset, 10)
+	require.NoError(t, err)
+
+	var waits []time.Duration
+	player.after = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Time{}
+		return ch
+	}
+
+	require.NoError(t, player.Run(context.Background()))
+	assert.Equal(t, []int{1, 2, 3, 4}, rec.ids)
+	// 10s and 50s gaps at 10x speed; simultaneous events are not delayed
+	assert.Equal(t, []time.Duration{time.Second, 5 * time.Second}, waits)
+
+	status := player.Status()
+	assert.Equal(t, 4, status.Position)
+	assert.Equal(t, 4, status.Total)
+	assert.Equal(t, at("10:01:00.000"), status.Clock)
+}
+
+func TestPlayer_Instant(t *testing.T) {
+	rec := &recorder{}
+	player, err := NewPlayer(testEvents(), rec.sink, rec.reset, Instant)
+	require.NoError(t, err)
+	player.after = func(d time.Duration) <-chan time.Time {
+		t.Fatalf("instant replay must not wait, got %v", d)
+		return nil
+	}
+
+	require.NoError(t, player.Run(context.Background()))
+	assert.Equal(t, []int{1, 2, 3, 4}, rec.ids)
+}
+
+func TestPlayer_PauseAndSeek(t *testing.T) {
+	rec := &recorder{}
+	player, err := NewPlayer(testEvents(), rec.sink, rec.reset, 1)
+	require.NoError(t, err)
+
+	// Timers never fire, so only commands move the replay forward
+	player.after = func(time.Duration) <-chan time.Time { return nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- player.Run(ctx) }()
+
+	player.Pause()
+	player.Seek(at("10:00:30.000"))
+	player.Seek(at("10:00:05.000"))
+	player.SetSpeed(Instant)
+	player.Resume()
+
+	require.NoError(t, <-done)
+	cancel()
+	// The backwards seek reset the recorder and replayed only the first event before resuming
+	assert.Equal(t, []int{1, 2, 3, 4}, rec.ids)
+	assert.Equal(t, float64(Instant), player.Status().Speed)
+	assert.False(t, player.Status().Paused)
+}
+
+func TestPlayer_SeekBackwardsResets(t *testing.T) {
+	rec := &recorder{}
+	player, err := NewPlayer(testEvents(), rec.sink, rec.reset, Instant)
+	require.NoError(t, err)
+
+	require.NoError(t, player.seek(at("10:00:10.000")))
+	assert.Equal(t, []int{1, 2, 3}, rec.ids)
+	assert.Equal(t, 3, player.Status().Position)
+
+	require.NoError(t, player.seek(at("10:00:00.000")))
+	assert.Equal(t, []int{1}, rec.ids)
+	assert.Equal(t, at("10:00:00.000"), player.Status().Clock)
+}
+
+func TestPlayer_Cancel(t *testing.T) {
+	rec := &recorder{}
+	player, err := NewPlayer(testEvents(), rec.sink, rec.reset, 1)
+	require.NoError(t, err)
+	player.after = func(time.Duration) <-chan time.Time { return nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, player.Run(ctx), context.Canceled)
+}
+
+func TestPlayer_CommandsAfterRun(t *testing.T) {
+	rec := &recorder{}
+	player, err := NewPlayer(testEvents(), rec.sink, rec.reset, Instant)
+	require.NoError(t, err)
+	require.NoError(t, player.Run(context.Background()))
+
+	// More commands than the queue holds must not block once the replay is over
+	for i := 0; i < 20; i++ {
+		player.TogglePause()
+		player.SeekBy(-time.Second)
+	}
+	assert.Equal(t, []int{1, 2, 3, 4}, rec.ids)
+}
+
+func TestNewPlayer_InvalidSpeed(t *testing.T) {
+	_, err := NewPlayer(testEvents(), (&recorder{}).sink, nil, -1)
+	assert.ErrorIs(t, err, ErrInvalidSpeed)
+}
@@ -0,0 +1,42 @@
+package report
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+	"strings"
+)
+
+//go:embed templates/results.html
+var resultsTemplateSource string
+
+var resultsTemplate = template.Must(template.New("results").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"lapColumns": func(n int) []int {
+		columns := make([]int, n)
+		for i := range columns {
+			columns[i] = i + 1
+		}
+		return columns
+	},
+	"lap": func(laps []string, column int) string {
+		if column > len(laps) {
+			return ""
+		}
+		return laps[column-1]
+	},
+}).Parse(resultsTemplateSource))
+
+// HTMLOptions controls the rendering of the HTML results page
+type HTMLOptions struct {
+	// Refresh is the auto-refresh interval in seconds, 0 disables it
+	Refresh int
+}
+
+// WriteHTML renders the results page as a self-contained HTML document
+func WriteHTML(w io.Writer, page *ResultsPage, opts HTMLOptions) error {
+	return resultsTemplate.Execute(w, struct {
+		*ResultsPage
+		Refresh int
+	}{page, opts.Refresh})
+}
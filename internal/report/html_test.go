@@ -0,0 +1,73 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHTML(t *testing.T) {
+	page := NewResultsPage(newTestCompetition(t), time.Date(2024, 1, 13, 12, 0, 0, 0, time.UTC))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, page, HTMLOptions{}))
+	html := buf.String()
+
+	assert.Contains(t, html, "<title>Club Sprint</title>")
+	assert.Contains(t, html, "<span>2024-01-13</span>")
+	assert.Contains(t, html, `<th class="num">Lap 1</th><th class="num">Lap 2</th>`)
+	assert.Contains(t, html, "<td>Jonas Keller</td>")
+	assert.Contains(t, html, "<h2>Did not finish</h2>")
+	assert.Contains(t, html, "<td>Broken ski</td>")
+	assert.Contains(t, html, "<h2>Did not start</h2>")
+	assert.NotContains(t, html, "<h2>Disqualified</h2>")
+	assert.NotContains(t, html, `http-equiv="refresh"`)
+	assert.Contains(t, html, "Generated 2024-01-13 12:00:00")
+}
+
+func TestWriteHTML_Refresh(t *testing.T) {
+	page := NewResultsPage(newTestCompetition(t), time.Now())
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, page, HTMLOptions{Refresh: 15}))
+	assert.Contains(t, buf.String(), `<meta http-equiv="refresh" content="15">`)
+}
+
+func TestWriteHTML_Escaping(t *testing.T) {
+	page := NewResultsPage(newTestCompetition(t), time.Now())
+	page.Title = "<script>alert(1)</script>"
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, page, HTMLOptions{}))
+	assert.NotContains(t, buf.String(), "<script>")
+}
+
+func TestWriteHTML_Warnings(t *testing.T) {
+	page := NewResultsPage(newTestCompetition(t), time.Now())
+	page.Sections[0].Rows[0].Warnings = []string{"lap 2 at 40.000 m/s is implausible"}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, page, HTMLOptions{}))
+	assert.Contains(t, buf.String(), `<span class="warning" title="Check timing: lap 2 at 40.000 m/s is implausible">!</span>`)
+}
+
+func TestWriteHTML_Language(t *testing.T) {
+	page := NewResultsPage(newTestCompetition(t), time.Date(2024, 1, 13, 12, 0, 0, 0, time.UTC))
+	printer, err := i18n.New("no")
+	require.NoError(t, err)
+	page.Printer = printer
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHTML(&buf, page, HTMLOptions{}))
+	html := buf.String()
+
+	assert.Contains(t, html, `<html lang="no">`)
+	assert.Contains(t, html, `<th class="num">Runde 1</th><th class="num">Runde 2</th>`)
+	assert.Contains(t, html, "<th>Skyting</th>")
+	assert.Contains(t, html, "<h2>Fullførte ikke</h2>")
+	assert.Contains(t, html, "Generert 2024-01-13 12:00:00")
+}
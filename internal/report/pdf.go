@@ -0,0 +1,252 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// PDFOptions controls the layout of the official result sheets
+type PDFOptions struct {
+	// PageSize is an fpdf page size name such as "A4" or "Letter", A4 by default
+	PageSize string
+}
+
+type pdfColumn struct {
+	title string // message key of the heading
+	width float64
+	align string
+	value func(Row) string
+}
+
+var resultColumns = []pdfColumn{
+	{"column.rank", 13, "R", func(r Row) string { return fmt.Sprintf("%d", r.Rank) }},
+	{"column.bib", 15, "R", func(r Row) string { return fmt.Sprintf("%d", r.Bib) }},
+	{"column.name", 41, "L", func(r Row) string { return r.Name }},
+	{"column.club", 35, "L", func(r Row) string { return r.Affiliation }},
+	{"column.shooting", 18, "C", func(r Row) string { return r.Shooting }},
+	{"column.penalty", 20, "R", func(r Row) string { return r.Penalties }},
+	{"column.time", 24, "R", func(r Row) string { return flagged(r, r.Time) }},
+	{"column.behind", 24, "R", func(r Row) string { return r.Behind }},
+}
+
+var unrankedColumns = []pdfColumn{
+	{"column.bib", 15, "R", func(r Row) string { return fmt.Sprintf("%d", r.Bib) }},
+	{"column.name", 55, "L", func(r Row) string { return r.Name }},
+	{"column.club", 44, "L", func(r Row) string { return r.Affiliation }},
+	{"column.category", 30, "L", func(r Row) string { return r.Category }},
+	{"column.comment", 46, "L", func(r Row) string { return r.Comment }},
+}
+
+const (
+	pdfMargin    = 10.0
+	pdfRowHeight = 5.5
+	pdfFont      = "DejaVu"
+)
+
+// The result sheets use a Unicode font so that every catalog language and athlete
+// name prints, see fonts/README.md
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	fontRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	fontBold []byte
+	//go:embed fonts/DejaVuSansCondensed-Oblique.ttf
+	fontItalic []byte
+)
+
+// pdfWriter wraps fpdf with the helpers shared by the result sheet sections
+type pdfWriter struct {
+	pdf  *fpdf.Fpdf
+	page *ResultsPage
+}
+
+// WritePDF renders the official result sheets as a paginated PDF document
+func WritePDF(w io.Writer, page *ResultsPage, opts PDFOptions) error {
+	size := opts.PageSize
+	if size == "" {
+		size = "A4"
+	}
+
+	pdf := fpdf.New("P", "mm", size, "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, 2*pdfMargin)
+	pdf.AliasNbPages("")
+	pdf.SetTitle(page.Title, true)
+	pdf.SetCreator("biathlon-tracker", true)
+	pdf.SetCreationDate(page.Generated)
+	pdf.AddUTF8FontFromBytes(pdfFont, "", fontRegular)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", fontBold)
+	pdf.AddUTF8FontFromBytes(pdfFont, "I", fontItalic)
+
+	writer := &pdfWriter{pdf: pdf, page: page}
+	pdf.SetHeaderFunc(writer.header)
+	pdf.SetFooterFunc(writer.footer)
+
+	pdf.AddPage()
+	writer.raceInfo()
+	for _, section := range page.Sections {
+		title := section.Category
+		if title == "" {
+			title = page.T("report.results")
+		}
+		writer.table(title, resultColumns, section.Rows)
+	}
+	for _, section := range page.Unranked() {
+		writer.table(section.Title, unrankedColumns, section.Rows)
+	}
+	writer.signatures()
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+func (p *pdfWriter) header() {
+	p.pdf.SetFont(pdfFont, "B", 14)
+	p.pdf.CellFormat(0, 7, p.page.Title, "", 1, "C", false, 0, "")
+
+	details := make([]string, 0, 2)
+	if p.page.Date != "" {
+		details = append(details, p.page.Date)
+	}
+	if p.page.Venue != "" {
+		details = append(details, p.page.Venue)
+	}
+	if len(details) > 0 {
+		p.pdf.SetFont(pdfFont, "", 10)
+		p.pdf.CellFormat(0, 5, strings.Join(details, " - "), "", 1, "C", false, 0, "")
+	}
+
+	p.pdf.Ln(1)
+	x, y := p.pdf.GetXY()
+	width, _ := p.pdf.GetPageSize()
+	p.pdf.Line(x, y, width-pdfMargin, y)
+	p.pdf.Ln(3)
+}
+
+func (p *pdfWriter) footer() {
+	p.pdf.SetY(-15)
+	p.pdf.SetFont(pdfFont, "", 8)
+	timing := ""
+	if p.page.TimingProvider != "" {
+		timing = p.page.T("report.timing", p.page.TimingProvider)
+	}
+	p.pdf.CellFormat(70, 5, timing, "", 0, "L", false, 0, "")
+	p.pdf.CellFormat(50, 5, p.page.Generated.Format("2006-01-02 15:04"), "", 0, "C", false, 0, "")
+	p.pdf.CellFormat(0, 5, p.page.T("report.page", p.pdf.PageNo(), "{nb}"), "", 0, "R", false, 0, "")
+}
+
+// raceInfo prints the jury, weather and course details below the first page header
+func (p *pdfWriter) raceInfo() {
+	config := p.page.Config
+	lines := make([]string, 0, len(p.page.Jury)+2)
+	for _, member := range p.page.Jury {
+		lines = append(lines, fmt.Sprintf("%s: %s", member.Role, member.Name))
+	}
+	if p.page.Weather != "" {
+		lines = append(lines, p.page.T("report.weather", p.page.Weather))
+	}
+	lines = append(lines, p.page.T("report.courseInfo", strings.Join([]string{
+		p.page.T("report.course", config.Laps, config.LapLen),
+		p.page.T("report.penaltyLoop", config.PenaltyLen),
+		p.page.T("report.firingLines", config.FiringLines),
+		p.page.T("report.start", config.Start, config.StartDelta),
+	}, ", ")))
+
+	p.pdf.SetFont(pdfFont, "", 9)
+	for _, line := range lines {
+		p.pdf.CellFormat(0, 4.5, line, "", 1, "L", false, 0, "")
+	}
+	p.pdf.Ln(4)
+}
+
+func (p *pdfWriter) table(title string, columns []pdfColumn, rows []Row) {
+	// Keep the section title together with the column header and the first row
+	p.ensureSpace(7 + 2*pdfRowHeight)
+	p.pdf.SetFont(pdfFont, "B", 11)
+	p.pdf.CellFormat(0, 7, title, "", 1, "L", false, 0, "")
+	p.columnHeader(columns)
+
+	p.pdf.SetFont(pdfFont, "", 9)
+	warnings := false
+	for i, row := range rows {
+		warnings = warnings || len(row.Warnings) > 0
+		if p.ensureSpace(pdfRowHeight) {
+			p.columnHeader(columns)
+			p.pdf.SetFont(pdfFont, "", 9)
+		}
+		fill := i%2 == 1
+		p.pdf.SetFillColor(245, 245, 245)
+		for _, column := range columns {
+			text := p.fit(column.value(row), column.width)
+			p.pdf.CellFormat(column.width, pdfRowHeight, text, "", 0, column.align, fill, 0, "")
+		}
+		p.pdf.Ln(-1)
+	}
+	if warnings {
+		p.pdf.SetFont(pdfFont, "I", 8)
+		p.pdf.CellFormat(0, pdfRowHeight, p.page.T("report.implausible"), "", 1, "L", false, 0, "")
+	}
+	p.pdf.Ln(4)
+}
+
+func (p *pdfWriter) columnHeader(columns []pdfColumn) {
+	p.pdf.SetFont(pdfFont, "B", 9)
+	p.pdf.SetFillColor(225, 225, 225)
+	for _, column := range columns {
+		p.pdf.CellFormat(column.width, pdfRowHeight, p.fit(p.page.T(column.title), column.width), "B", 0, column.align, true, 0, "")
+	}
+	p.pdf.Ln(-1)
+}
+
+// signatures prints a signature line for every jury member
+func (p *pdfWriter) signatures() {
+	if len(p.page.Jury) == 0 {
+		return
+	}
+	p.ensureSpace(10 + float64(len(p.page.Jury))*12)
+	p.pdf.Ln(6)
+	p.pdf.SetFont(pdfFont, "", 9)
+	for _, member := range p.page.Jury {
+		p.pdf.CellFormat(80, 10, fmt.Sprintf("%s: %s", member.Role, member.Name), "", 0, "L", false, 0, "")
+		p.pdf.CellFormat(70, 10, "", "B", 1, "L", false, 0, "")
+		p.pdf.Ln(2)
+	}
+}
+
+// ensureSpace starts a new page when less than height is left on the current one
+func (p *pdfWriter) ensureSpace(height float64) bool {
+	_, pageHeight := p.pdf.GetPageSize()
+	if p.pdf.GetY()+height <= pageHeight-2*pdfMargin {
+		return false
+	}
+	p.pdf.AddPage()
+	return true
+}
+
+// flagged marks a value of a row with timing warnings
+func flagged(r Row, value string) string {
+	if len(r.Warnings) == 0 {
+		return value
+	}
+	return value + " !"
+}
+
+// fit truncates text so that it fits into a cell of the given width
+func (p *pdfWriter) fit(text string, width float64) string {
+	limit := width - 2*p.pdf.GetCellMargin()
+	if p.pdf.GetStringWidth(text) <= limit {
+		return text
+	}
+	for len(text) > 0 && p.pdf.GetStringWidth(text+"...") > limit {
+		_, size := utf8.DecodeLastRuneInString(text)
+		text = text[:len(text)-size]
+	}
+	return text + "..."
+}
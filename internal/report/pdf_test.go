@@ -0,0 +1,118 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pageCountRegex = regexp.MustCompile(`/Count (\d+)`)
+
+var (
+	streamRegex = regexp.MustCompile(`(?s)stream\r?\n(.*?)endstream`)
+	textRegex   = regexp.MustCompile(`(?s)\(((?:\\.|[^\\)])*)\)Tj`)
+	escapeRegex = regexp.MustCompile(`(?s)\\(.)`)
+)
+
+// pdfText returns the text shown on the pages, written by fpdf as UTF-16BE strings
+// of the UTF-8 fonts in compressed content streams
+func pdfText(t *testing.T, data []byte) string {
+	t.Helper()
+	var text strings.Builder
+	for _, stream := range streamRegex.FindAllSubmatch(data, -1) {
+		reader, err := zlib.NewReader(bytes.NewReader(stream[1]))
+		if err != nil {
+			continue
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			continue
+		}
+		for _, match := range textRegex.FindAllSubmatch(content, -1) {
+			raw := escapeRegex.ReplaceAllFunc(match[1], func(escaped []byte) []byte {
+				if escaped[1] == 'r' {
+					return []byte{'\r'}
+				}
+				return escaped[1:]
+			})
+			units := make([]uint16, 0, len(raw)/2)
+			for i := 0; i+1 < len(raw); i += 2 {
+				units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+			}
+			text.WriteString(string(utf16.Decode(units)))
+			text.WriteString("\n")
+		}
+	}
+	return text.String()
+}
+
+func pdfPageCount(t *testing.T, data []byte) int {
+	t.Helper()
+	matches := pageCountRegex.FindSubmatch(data)
+	require.NotNil(t, matches, "page tree not found")
+	count, err := strconv.Atoi(string(matches[1]))
+	require.NoError(t, err)
+	return count
+}
+
+func TestWritePDF(t *testing.T) {
+	page := NewResultsPage(newTestCompetition(t), time.Date(2024, 1, 13, 12, 0, 0, 0, time.UTC))
+	page.Jury = []domain.JuryMember{{Role: "Technical Delegate", Name: "Åse Lund"}}
+	page.Weather = "Sunny, -5 °C"
+	page.TimingProvider = "Club Timing"
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePDF(&buf, page, PDFOptions{}))
+
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	assert.Equal(t, 1, pdfPageCount(t, buf.Bytes()))
+}
+
+func TestWritePDF_Russian(t *testing.T) {
+	page := NewResultsPage(newTestCompetition(t), time.Date(2024, 1, 13, 12, 0, 0, 0, time.UTC))
+	printer, err := i18n.New("ru")
+	require.NoError(t, err)
+	page.Printer = printer
+	page.Jury = []domain.JuryMember{{Role: "Технический делегат", Name: "Анна Петрова"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePDF(&buf, page, PDFOptions{}))
+
+	text := pdfText(t, buf.Bytes())
+	assert.Contains(t, text, "Технический делегат: Анна Петрова")
+	assert.Contains(t, text, "Трасса: 2 × 3000 м")
+	assert.Contains(t, text, "Место")
+	assert.Contains(t, text, "Отставание")
+	assert.Contains(t, text, "+00:02:00.000")
+	assert.Contains(t, text, "Стр. 1/1")
+}
+
+func TestWritePDF_Pagination(t *testing.T) {
+	page := NewResultsPage(newTestCompetition(t), time.Now())
+	rows := make([]Row, 0, 150)
+	for i := 1; i <= 150; i++ {
+		rows = append(rows, Row{
+			Rank: i,
+			Bib:  i,
+			Name: fmt.Sprintf("Athlete with a particularly long name number %d", i),
+			Time: "00:25:00.000",
+		})
+	}
+	page.Sections = []Section{{Category: "Men", Rows: rows}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePDF(&buf, page, PDFOptions{PageSize: "A4"}))
+	assert.GreaterOrEqual(t, pdfPageCount(t, buf.Bytes()), 3)
+}
@@ -0,0 +1,182 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/i18n"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+// ResultsPage is the presentation model shared by the published result documents
+type ResultsPage struct {
+	Title          string
+	Date           string
+	Venue          string
+	Jury           []domain.JuryMember
+	Weather        string
+	TimingProvider string
+	Config         *domain.Config
+	Generated      time.Time
+	Sections       []Section
+	NotFinished    []Row
+	NotStarted     []Row
+	Disqualified   []Row
+	// Printer translates the headings, English when nil
+	Printer *i18n.Printer
+}
+
+// T returns the message with the given key in the language of the page
+func (p *ResultsPage) T(key string, args ...interface{}) string {
+	return p.Printer.Sprintf(key, args...)
+}
+
+// Language returns the language tag of the page
+func (p *ResultsPage) Language() string {
+	return p.Printer.Language()
+}
+
+// Section is the ranked table of one category
+type Section struct {
+	Category string
+	Laps     int
+	Rows     []Row
+}
+
+// Row is a single competitor line of a results table
+type Row struct {
+	Rank        int
+	Bib         int
+	Name        string
+	Affiliation string
+	Category    string
+	Time        string
+	Behind      string
+	Shooting    string
+	Hits        int
+	Shots       int
+	Laps        []string
+	Penalties   string
+	Ski         string // ski time with its rank in the field, finishers only
+	Range       string // range time with its rank in the field, finishers only
+	Comment     string
+	Warnings    []string // timing problems flagged while processing the events
+}
+
+// UnrankedSection lists competitors without a valid finish
+type UnrankedSection struct {
+	Title string
+	Rows  []Row
+}
+
+// Unranked returns the non-empty sections of competitors without a rank
+func (p *ResultsPage) Unranked() []UnrankedSection {
+	sections := make([]UnrankedSection, 0, 3)
+	for _, section := range []UnrankedSection{
+		{Title: p.T("report.notFinished"), Rows: p.NotFinished},
+		{Title: p.T("report.notStarted"), Rows: p.NotStarted},
+		{Title: p.T("report.disqualified"), Rows: p.Disqualified},
+	} {
+		if len(section.Rows) > 0 {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// NewResultsPage builds the results page model from the current state of the competition,
+// in the language of the competition
+func NewResultsPage(competition *service.CompetitionService, generated time.Time) *ResultsPage {
+	config := competition.Config()
+	page := &ResultsPage{
+		Title:          config.Name,
+		Date:           config.Date,
+		Venue:          config.Venue,
+		Jury:           config.Jury,
+		Weather:        config.Weather,
+		TimingProvider: config.TimingProvider,
+		Config:         config,
+		Generated:      generated,
+		Printer:        competition.Printer(),
+	}
+	if page.Title == "" {
+		page.Title = page.T("report.title")
+	}
+
+	for _, group := range competition.GetCategoryResults() {
+		section := Section{Category: group.Category}
+		for _, result := range group.Results {
+			if result.Rank == 0 {
+				continue
+			}
+			row := page.newRow(result, group.Category)
+			if len(row.Laps) > section.Laps {
+				section.Laps = len(row.Laps)
+			}
+			section.Rows = append(section.Rows, row)
+		}
+		if len(section.Rows) > 0 {
+			page.Sections = append(page.Sections, section)
+		}
+
+		for _, result := range group.Results {
+			switch result.Status {
+			case domain.StatusNotFinished:
+				page.NotFinished = append(page.NotFinished, page.newRow(result, group.Category))
+			case domain.StatusNotStarted:
+				page.NotStarted = append(page.NotStarted, page.newRow(result, group.Category))
+			case domain.StatusDisqualified:
+				page.Disqualified = append(page.Disqualified, page.newRow(result, group.Category))
+			}
+		}
+	}
+	return page
+}
+
+func (p *ResultsPage) newRow(result *service.Result, category string) Row {
+	competitor := result.Competitor
+	row := Row{
+		Rank:     result.Rank,
+		Bib:      competitor.ID,
+		Name:     competitor.Name(),
+		Category: category,
+		Hits:     competitor.Hits,
+		Shots:    len(competitor.Ranges) * domain.TargetsPerRange,
+		Comment:  competitor.Comment,
+		Warnings: competitor.Warnings,
+	}
+	if athlete := competitor.Athlete; athlete != nil {
+		row.Bib = athlete.Bib
+		row.Affiliation = athlete.Affiliation()
+	}
+	if row.Name == "" {
+		row.Name = p.T("report.competitor", competitor.ID)
+	}
+	if result.Rank > 0 {
+		row.Time = service.FormatDuration(result.Time)
+		row.Behind = "+" + service.FormatDuration(result.Behind)
+		row.Ski = fmt.Sprintf("%s (%d)", service.FormatDuration(result.SkiTime), result.SkiRank)
+		row.Range = fmt.Sprintf("%s (%d)", service.FormatDuration(result.RangeTime), result.RangeRank)
+	}
+
+	shooting := make([]string, 0, len(competitor.Ranges))
+	for _, visit := range competitor.Ranges {
+		shooting = append(shooting, fmt.Sprintf("%d", visit.Hits))
+	}
+	row.Shooting = strings.Join(shooting, "+")
+
+	for _, lap := range competitor.Laps {
+		row.Laps = append(row.Laps, service.FormatDuration(lap.Time))
+	}
+
+	var penaltyTime time.Duration
+	for _, penalty := range competitor.Penalties {
+		penaltyTime += penalty.Time
+	}
+	if len(competitor.Penalties) > 0 {
+		row.Penalties = service.FormatDuration(penaltyTime)
+	}
+	return row
+}
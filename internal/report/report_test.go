@@ -0,0 +1,104 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCompetition runs a small two-lap race: competitor 1 finishes with a
+// penalty loop, 2 finishes clean, 3 gives up and 4 never starts
+func newTestCompetition(t *testing.T) *service.CompetitionService {
+	t.Helper()
+	config := testutil.Config()
+	config.Laps = 2
+	competition := service.NewCompetitionService(config)
+	athletes, err := domain.NewAthleteRegistry([]*domain.Athlete{
+		{CompetitorID: 1, Name: "Ingrid Solberg", Club: "Lillehammer SK", Nation: "NOR"},
+		{CompetitorID: 2, Name: "Jonas Keller", Nation: "GER"},
+	})
+	require.NoError(t, err)
+	competition.SetAthletes(athletes)
+
+	events := []*domain.Event{
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 2, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 3, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 4, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 2, "10:01:00.000"),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 3, "10:02:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:01:00.000", domain.EventStarted, 2, ""),
+		testutil.Incoming("10:02:00.000", domain.EventStarted, 3, ""),
+		testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1"),
+		testutil.Incoming("10:05:10.000", domain.EventTargetHit, 1, "1"),
+		testutil.Incoming("10:05:20.000", domain.EventTargetHit, 1, "2"),
+		testutil.Incoming("10:05:30.000", domain.EventTargetHit, 1, "3"),
+		testutil.Incoming("10:05:40.000", domain.EventTargetHit, 1, "4"),
+		testutil.Incoming("10:05:50.000", domain.EventLeftFiringRange, 1, ""),
+		testutil.Incoming("10:06:00.000", domain.EventEnteredPenaltyLaps, 1, ""),
+		testutil.Incoming("10:06:30.000", domain.EventLeftPenaltyLaps, 1, ""),
+		testutil.Incoming("10:06:40.000", domain.EventCannotContinue, 3, "Broken ski"),
+		testutil.Incoming("10:10:00.000", domain.EventEndedMainLap, 1, ""),
+		testutil.Incoming("10:10:30.000", domain.EventEndedMainLap, 2, ""),
+		testutil.Incoming("10:20:00.000", domain.EventEndedMainLap, 1, ""),
+		testutil.Incoming("10:19:00.000", domain.EventEndedMainLap, 2, ""),
+	}
+	for _, event := range events {
+		require.NoError(t, competition.ProcessEvent(event))
+	}
+	return competition
+}
+
+func TestNewResultsPage(t *testing.T) {
+	generated := time.Date(2024, 1, 13, 12, 0, 0, 0, time.UTC)
+	page := NewResultsPage(newTestCompetition(t), generated)
+
+	assert.Equal(t, "Club Sprint", page.Title)
+	assert.Equal(t, "2024-01-13", page.Date)
+	assert.Equal(t, generated, page.Generated)
+
+	require.Len(t, page.Sections, 1)
+	section := page.Sections[0]
+	assert.Equal(t, 2, section.Laps)
+	require.Len(t, section.Rows, 2)
+
+	first := section.Rows[0]
+	assert.Equal(t, 1, first.Rank)
+	assert.Equal(t, "Jonas Keller", first.Name)
+	assert.Equal(t, "GER", first.Affiliation)
+	assert.Equal(t, "00:18:00.000", first.Time)
+	assert.Equal(t, "+00:00:00.000", first.Behind)
+	assert.Equal(t, "00:18:00.000 (1)", first.Ski)
+	assert.Equal(t, "00:00:00.000 (1)", first.Range)
+
+	second := section.Rows[1]
+	assert.Equal(t, 2, second.Rank)
+	assert.Equal(t, "Lillehammer SK (NOR)", second.Affiliation)
+	assert.Equal(t, "+00:02:00.000", second.Behind)
+	assert.Equal(t, "4", second.Shooting)
+	assert.Equal(t, 4, second.Hits)
+	assert.Equal(t, 5, second.Shots)
+	assert.Equal(t, "00:00:30.000", second.Penalties)
+	assert.Equal(t, "00:18:40.000 (2)", second.Ski)
+	assert.Equal(t, "00:00:50.000 (2)", second.Range)
+	assert.Equal(t, "00:10:00.000", second.Laps[0])
+
+	require.Len(t, page.NotFinished, 1)
+	assert.Equal(t, "Competitor 3", page.NotFinished[0].Name)
+	assert.Equal(t, "Broken ski", page.NotFinished[0].Comment)
+	require.Len(t, page.NotStarted, 1)
+	assert.Equal(t, 4, page.NotStarted[0].Bib)
+	assert.Empty(t, page.Disqualified)
+
+	unranked := page.Unranked()
+	require.Len(t, unranked, 2)
+	assert.Equal(t, "Did not finish", unranked[0].Title)
+	assert.Equal(t, "Did not start", unranked[1].Title)
+}
@@ -2,9 +2,11 @@ package service
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/i18n"
 )
 
 // CompetitionService handles the biathlon competition logic
@@ -13,6 +15,31 @@ type CompetitionService struct {
 	competitors map[int]*domain.Competitor
 	events      []*domain.Event
 	log         []string
+	logMeta     []logMeta // what each log entry is about
+	logSeq      int       // position of the latest log entry, see GetNewLogEntries
+	athletes    domain.AthleteRegistry
+	handlers    []EventHandler
+	printer     *i18n.Printer // language of the event log
+
+	// Duplicate detection: identities of the applied events, the latest applied event
+	// per event, competitor and parameters, and the ignored duplicates
+	seen       map[string]bool
+	recent     map[string]*domain.Event
+	duplicates []*domain.Event
+
+	// replaying is set while a competitor is reprocessed; handlers are not called
+	replaying bool
+}
+
+// logMeta records what a log entry is about, so the entries of a competitor can be
+// replaced when it is reprocessed and the log can be filtered
+type logMeta struct {
+	time         time.Time
+	competitorID int
+	eventID      int
+	kind         LogKind
+	firingLine   int
+	seq          int // position in the order the entries were added
 }
 
 // NewCompetitionService creates a new competition service
@@ -22,36 +49,85 @@ func NewCompetitionService(config *domain.Config) *CompetitionService {
 		competitors: make(map[int]*domain.Competitor),
 		events:      make([]*domain.Event, 0),
 		log:         make([]string, 0),
+		athletes:    make(domain.AthleteRegistry),
+		seen:        make(map[string]bool),
+		recent:      make(map[string]*domain.Event),
+		printer:     i18n.English(),
+	}
+}
+
+// Reset discards all processed events and competitors, keeping the config, athletes
+// and language
+func (s *CompetitionService) Reset() {
+	s.competitors = make(map[int]*domain.Competitor)
+	s.events = make([]*domain.Event, 0)
+	s.log = make([]string, 0)
+	s.logMeta = nil
+	s.seen = make(map[string]bool)
+	s.recent = make(map[string]*domain.Event)
+	s.duplicates = nil
+}
+
+// SetLanguage selects the catalog of the event log messages; entries logged before
+// are kept as they are
+func (s *CompetitionService) SetLanguage(language string) error {
+	printer, err := i18n.New(language)
+	if err != nil {
+		return err
 	}
+	s.printer = printer
+	return nil
+}
+
+// Printer returns the message printer of the competition's language, for documents
+// rendered in the same language as its log
+func (s *CompetitionService) Printer() *i18n.Printer {
+	return s.printer
+}
+
+// Config returns the configuration of the competition
+func (s *CompetitionService) Config() *domain.Config {
+	return s.config
+}
+
+// SetAthletes attaches athlete profiles to competitors, including those already registered
+func (s *CompetitionService) SetAthletes(athletes domain.AthleteRegistry) {
+	s.athletes = athletes
+	for id, competitor := range s.competitors {
+		competitor.Athlete = athletes[id]
+	}
+}
+
+// Athletes returns the athlete profiles attached with SetAthletes
+func (s *CompetitionService) Athletes() domain.AthleteRegistry {
+	return s.athletes
+}
+
+// competitorLabel renders a competitor reference for the event log
+func (s *CompetitionService) competitorLabel(id int) string {
+	if athlete, ok := s.athletes[id]; ok {
+		return s.printer.Sprintf("competitor.named", id, athlete.Name)
+	}
+	return s.printer.Sprintf("competitor", id)
+}
+
+// categoryOf returns the category of a competitor, see Config.CategoryFor
+func (s *CompetitionService) categoryOf(competitor *domain.Competitor) string {
+	return s.config.CategoryFor(competitor.ID, competitor.Athlete)
+}
+
+// rulesFor returns the race rules that apply to the competitor's category
+func (s *CompetitionService) rulesFor(competitor *domain.Competitor) domain.Rules {
+	return s.config.RulesFor(s.categoryOf(competitor))
 }
 
 func (s *CompetitionService) formatEventMessage(event *domain.Event) string {
-	timeStr := event.Time.Format("15:04:05.000")
-	switch domain.IncomingEventID(event.EventID) {
-	case domain.EventRegistered:
-		return fmt.Sprintf("[%s] The competitor(%d) registered", timeStr, event.CompetitorID)
-	case domain.EventStartTimeSet:
-		return fmt.Sprintf("[%s] The start time for the competitor(%d) was set by a draw to %s", timeStr, event.CompetitorID, event.ExtraParams)
-	case domain.EventOnStartLine:
-		return fmt.Sprintf("[%s] The competitor(%d) is on the start line", timeStr, event.CompetitorID)
-	case domain.EventStarted:
-		return fmt.Sprintf("[%s] The competitor(%d) has started", timeStr, event.CompetitorID)
-	case domain.EventOnFiringRange:
-		return fmt.Sprintf("[%s] The competitor(%d) is on the firing range(%s)", timeStr, event.CompetitorID, event.ExtraParams)
-	case domain.EventTargetHit:
-		return fmt.Sprintf("[%s] The target(%s) has been hit by competitor(%d)", timeStr, event.ExtraParams, event.CompetitorID)
-	case domain.EventLeftFiringRange:
-		return fmt.Sprintf("[%s] The competitor(%d) left the firing range", timeStr, event.CompetitorID)
-	case domain.EventEnteredPenaltyLaps:
-		return fmt.Sprintf("[%s] The competitor(%d) entered the penalty laps", timeStr, event.CompetitorID)
-	case domain.EventLeftPenaltyLaps:
-		return fmt.Sprintf("[%s] The competitor(%d) left the penalty laps", timeStr, event.CompetitorID)
-	case domain.EventEndedMainLap:
-		return fmt.Sprintf("[%s] The competitor(%d) ended the main lap", timeStr, event.CompetitorID)
-	case domain.EventCannotContinue:
-		return fmt.Sprintf("[%s] The competitor(%d) can't continue: %s", timeStr, event.CompetitorID, event.ExtraParams)
+	id := domain.IncomingEventID(event.EventID)
+	if id < domain.EventRegistered || id > domain.EventCannotContinue {
+		return ""
 	}
-	return ""
+	return s.printer.Sprintf(i18n.IncomingEventKey(id),
+		event.Time.Format("15:04:05.000"), s.competitorLabel(event.CompetitorID), event.ExtraParams)
 }
 
 // ProcessEvent processes an incoming event
@@ -60,19 +136,36 @@ func (s *CompetitionService) ProcessEvent(event *domain.Event) error {
 		return fmt.Errorf("invalid event type: %v", event.Type)
 	}
 
+	// Retransmitted events are applied once
+	if s.isDuplicate(event) {
+		if s.config.RejectDuplicates() {
+			return fmt.Errorf("%w: %s", domain.ErrDuplicateEvent, event)
+		}
+		s.duplicates = append(s.duplicates, event)
+		return nil
+	}
+
 	competitor, exists := s.competitors[event.CompetitorID]
 	if !exists && event.EventID != int(domain.EventRegistered) {
 		return fmt.Errorf("competitor %d not registered", event.CompetitorID)
 	}
 
+	if err := s.handleBefore(event); err != nil {
+		return err
+	}
+	produced := len(s.events)
+	near := s.nearDuplicate(event)
+
 	// Log the event
 	if msg := s.formatEventMessage(event); msg != "" {
-		s.log = append(s.log, msg)
+		s.addLog(event, LogEvent, msg)
 	}
 
 	switch domain.IncomingEventID(event.EventID) {
 	case domain.EventRegistered:
-		s.competitors[event.CompetitorID] = domain.NewCompetitor(event.CompetitorID)
+		competitor = domain.NewCompetitor(event.CompetitorID)
+		competitor.Athlete = s.athletes[event.CompetitorID]
+		s.competitors[event.CompetitorID] = competitor
 	case domain.EventStartTimeSet:
 		competitor.PlannedStart = parseTime(event.ExtraParams)
 	case domain.EventOnStartLine:
@@ -82,35 +175,44 @@ func (s *CompetitionService) ProcessEvent(event *domain.Event) error {
 		competitor.StartTime = event.Time
 	case domain.EventOnFiringRange:
 		competitor.Status = domain.StatusOnFiringRange
+		line, _ := strconv.Atoi(event.ExtraParams)
+		competitor.EnterRange(line, event.Time)
 	case domain.EventTargetHit:
 		competitor.RecordShot(true)
 	case domain.EventLeftFiringRange:
 		competitor.Status = domain.StatusRacing
+		competitor.LeaveRange(event.Time)
 	case domain.EventEnteredPenaltyLaps:
 		competitor.Status = domain.StatusOnPenaltyLaps
-		competitor.TotalTime = event.Time.Sub(competitor.StartTime)
+		competitor.PenaltyStart = event.Time
 	case domain.EventLeftPenaltyLaps:
 		competitor.Status = domain.StatusRacing
-		penaltyTime := event.Time.Sub(competitor.StartTime) - competitor.TotalTime
-		speed := float64(s.config.PenaltyLen) / penaltyTime.Seconds()
+		penaltyTime := event.Time.Sub(competitor.PenaltyStart)
+		speed := 0.0
+		if penaltyTime > 0 {
+			speed = float64(s.rulesFor(competitor).PenaltyLen) / penaltyTime.Seconds()
+		}
+		s.checkPenaltySpeed(event, competitor, penaltyTime)
 		competitor.AddPenalty(penaltyTime, speed)
 	case domain.EventEndedMainLap:
 		competitor.Status = domain.StatusRacing
+		rules := s.rulesFor(competitor)
+		lapStart := competitor.StartTime
 		if len(competitor.Laps) > 0 {
-			lastLapEnd := competitor.TotalTime
-			lapTime := event.Time.Sub(competitor.StartTime) - lastLapEnd
-			speed := float64(s.config.LapLen) / lapTime.Seconds()
-			competitor.AddLap(lapTime, speed)
-		} else {
-			lapTime := event.Time.Sub(competitor.StartTime)
-			speed := float64(s.config.LapLen) / lapTime.Seconds()
-			competitor.AddLap(lapTime, speed)
+			lapStart = competitor.Laps[len(competitor.Laps)-1].End
 		}
-		if competitor.CurrentLap == s.config.Laps {
+		lapTime := event.Time.Sub(lapStart)
+		segment := s.printer.Sprintf("segment.lap", len(competitor.Laps)+1)
+		speed := s.checkSpeed(event, competitor, segment, rules.LapLen, lapTime, s.config.Limits().Lap)
+		competitor.AddLap(lapTime, speed)
+		competitor.Laps[len(competitor.Laps)-1].End = event.Time
+		if competitor.CurrentLap == rules.Laps {
 			competitor.Status = domain.StatusFinished
+			competitor.FinishTime = event.Time
 			finishEvent := domain.NewEvent(event.Time, domain.EventTypeOutgoing, int(domain.EventFinished), event.CompetitorID, "")
 			s.events = append(s.events, finishEvent)
-			s.log = append(s.log, fmt.Sprintf("[%s] The competitor(%d) has finished", event.Time.Format("15:04:05.000"), event.CompetitorID))
+			s.addLog(finishEvent, LogEvent, s.printer.Sprintf(i18n.EventKey(finishEvent.EventID),
+				event.Time.Format("15:04:05.000"), s.competitorLabel(event.CompetitorID)))
 		}
 	case domain.EventCannotContinue:
 		competitor.Status = domain.StatusNotFinished
@@ -119,10 +221,74 @@ func (s *CompetitionService) ProcessEvent(event *domain.Event) error {
 		s.events = append(s.events, disqualifyEvent)
 	}
 
+	if near != nil {
+		s.warnNearDuplicate(event, near)
+	}
+	s.remember(event)
+
+	outgoing := make([]*domain.Event, len(s.events)-produced)
+	copy(outgoing, s.events[produced:])
 	s.events = append(s.events, event)
+	s.handleAfter(event, outgoing)
 	return nil
 }
 
+// checkSpeed warns about a segment with a non-positive duration or an implausible
+// speed and returns the speed to record, 0 when the duration is not positive
+func (s *CompetitionService) checkSpeed(event *domain.Event, competitor *domain.Competitor, segment string,
+	distance int, d time.Duration, limits domain.SpeedRange) float64 {
+	if d <= 0 {
+		s.warn(event, competitor, s.printer.Sprintf("warning.took", segment, formatDelta(d)))
+		return 0
+	}
+	speed := float64(distance) / d.Seconds()
+	if !limits.Contains(speed) {
+		s.warn(event, competitor, s.printer.Sprintf("warning.speed", segment, speed, limits))
+	}
+	return speed
+}
+
+// checkPenaltySpeed checks the penalty loops against the distance actually skied,
+// one loop per target missed at the last firing range visit
+func (s *CompetitionService) checkPenaltySpeed(event *domain.Event, competitor *domain.Competitor, d time.Duration) {
+	misses := 1
+	if len(competitor.Ranges) > 0 {
+		misses = competitor.Ranges[len(competitor.Ranges)-1].Misses()
+	}
+	if misses <= 0 {
+		misses = 1
+	}
+	distance := misses * s.rulesFor(competitor).PenaltyLen
+	s.checkSpeed(event, competitor, s.printer.Sprintf("segment.penalty", distance), distance, d, s.config.Limits().Penalty)
+}
+
+// warn flags the competitor's result and adds the problem to the event log
+func (s *CompetitionService) warn(event *domain.Event, competitor *domain.Competitor, message string) {
+	competitor.Warn(message)
+	s.addLog(event, LogWarning, s.printer.Sprintf("warning",
+		event.Time.Format("15:04:05.000"), s.competitorLabel(competitor.ID), message))
+}
+
+// addLog appends an entry about the event's competitor to the event log. Entries of
+// range events record the firing line, for hits and exits that of the latest visit.
+func (s *CompetitionService) addLog(event *domain.Event, kind LogKind, message string) {
+	meta := logMeta{time: event.Time, competitorID: event.CompetitorID, eventID: event.EventID, kind: kind}
+	if event.Type == domain.EventTypeIncoming {
+		switch domain.IncomingEventID(event.EventID) {
+		case domain.EventOnFiringRange:
+			meta.firingLine, _ = strconv.Atoi(event.ExtraParams)
+		case domain.EventTargetHit, domain.EventLeftFiringRange:
+			if competitor := s.competitors[event.CompetitorID]; competitor != nil && len(competitor.Ranges) > 0 {
+				meta.firingLine = competitor.Ranges[len(competitor.Ranges)-1].Line
+			}
+		}
+	}
+	s.logSeq++
+	meta.seq = s.logSeq
+	s.log = append(s.log, message)
+	s.logMeta = append(s.logMeta, meta)
+}
+
 // GetEventLog returns the formatted event log
 func (s *CompetitionService) GetEventLog() string {
 	log := ""
@@ -135,11 +301,19 @@ func (s *CompetitionService) GetEventLog() string {
 // GetFinalReport generates the final report for all competitors
 func (s *CompetitionService) GetFinalReport() string {
 	report := ""
-	for _, competitor := range s.competitors {
+	for _, result := range s.GetResults() {
+		competitor := result.Competitor
 		status := getStatusString(competitor.Status)
 		laps := formatLaps(competitor.Laps)
 		penalties := formatPenalties(competitor.Penalties)
 		shots := fmt.Sprintf("%d/%d", competitor.Hits, competitor.Shots)
+		if len(competitor.Warnings) > 0 {
+			shots += " (check timing)"
+		}
+		if name := competitor.Name(); name != "" {
+			report += fmt.Sprintf("[%s] %d %s %s %s %s\n", status, competitor.ID, name, laps, penalties, shots)
+			continue
+		}
 		report += fmt.Sprintf("[%s] %d %s %s %s\n", status, competitor.ID, laps, penalties, shots)
 	}
 	return report
@@ -163,7 +337,8 @@ func getStatusString(status domain.CompetitorStatus) string {
 	}
 }
 
-func formatDuration(d time.Duration) string {
+// FormatDuration formats a duration as hh:mm:ss.sss
+func FormatDuration(d time.Duration) string {
 	totalSeconds := int(d.Seconds())
 	hours := totalSeconds / 3600
 	minutes := (totalSeconds % 3600) / 60
@@ -181,7 +356,7 @@ func formatLaps(laps []domain.LapInfo) string {
 		if i > 0 {
 			result += ", "
 		}
-		result += fmt.Sprintf("{%s, %.3f}", formatDuration(lap.Time), lap.Speed)
+		result += fmt.Sprintf("{%s, %.3f}", FormatDuration(lap.Time), lap.Speed)
 	}
 	result += "]"
 	return result
@@ -196,7 +371,7 @@ func formatPenalties(penalties []domain.PenaltyInfo) string {
 		if i > 0 {
 			result += ", "
 		}
-		result += fmt.Sprintf("{%s, %.3f}", formatDuration(penalty.Time), penalty.Speed)
+		result += fmt.Sprintf("{%s, %.3f}", FormatDuration(penalty.Time), penalty.Speed)
 	}
 	result += "}"
 	return result
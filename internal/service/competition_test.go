@@ -5,7 +5,10 @@ import (
 	"time"
 
 	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/i18n"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewCompetitionService(t *testing.T) {
@@ -156,6 +159,123 @@ func TestProcessEvent_Finish(t *testing.T) {
 	assert.Contains(t, service.log[5], "The competitor(1) has finished")
 }
 
+func TestProcessEvent_LapAndPenaltyTimes(t *testing.T) {
+	config := testutil.Config()
+	config.Laps = 2
+	config.LapLen = 3000
+	config.PenaltyLen = 150
+
+	service := NewCompetitionService(config)
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:04:00.000", domain.EventOnFiringRange, 1, "1"),
+		testutil.Incoming("10:04:10.000", domain.EventTargetHit, 1, "1"),
+		testutil.Incoming("10:04:12.000", domain.EventTargetHit, 1, "2"),
+		testutil.Incoming("10:04:14.000", domain.EventTargetHit, 1, "3"),
+		testutil.Incoming("10:04:30.000", domain.EventLeftFiringRange, 1, ""),
+		testutil.Incoming("10:04:40.000", domain.EventEnteredPenaltyLaps, 1, ""),
+		testutil.Incoming("10:05:40.000", domain.EventLeftPenaltyLaps, 1, ""),
+		testutil.Incoming("10:10:00.000", domain.EventEndedMainLap, 1, ""),
+		testutil.Incoming("10:20:00.000", domain.EventEndedMainLap, 1, ""),
+	)
+
+	competitor := service.competitors[1]
+	require.Len(t, competitor.Penalties, 1)
+	assert.Equal(t, time.Minute, competitor.Penalties[0].Time)
+	assert.Equal(t, 2.5, competitor.Penalties[0].Speed)
+
+	// The second lap is timed from the end of the first one, not from the penalty loop
+	require.Len(t, competitor.Laps, 2)
+	assert.Equal(t, 10*time.Minute, competitor.Laps[0].Time)
+	assert.Equal(t, 10*time.Minute, competitor.Laps[1].Time)
+	assert.Equal(t, 5.0, competitor.Laps[1].Speed)
+	assert.Empty(t, competitor.Warnings)
+}
+
+func TestProcessEvent_ImplausibleSpeeds(t *testing.T) {
+	config := testutil.Config()
+	config.Laps = 2
+	config.LapLen = 3000
+
+	// Distinct sequence numbers keep the second lap from being ignored as a duplicate
+	first := testutil.Incoming("10:01:00.000", domain.EventEndedMainLap, 1, "")
+	first.Seq = 1
+	second := testutil.Incoming("10:01:00.000", domain.EventEndedMainLap, 1, "")
+	second.Seq = 2
+
+	service := NewCompetitionService(config)
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		first,
+		second,
+	)
+
+	competitor := service.competitors[1]
+	assert.Equal(t, []string{
+		"lap 1 at 50.000 m/s is implausible, expected 1.0-12.0 m/s",
+		"lap 2 took +00:00:00.000",
+		"repeated event 10 +00:00:00.000 from the one at 10:01:00.000",
+	}, competitor.Warnings)
+	assert.Equal(t, 0.0, competitor.Laps[1].Speed)
+
+	log := service.GetEventLog()
+	assert.Contains(t, log, "[10:01:00.000] Warning: the competitor(1) lap 1 at 50.000 m/s is implausible, expected 1.0-12.0 m/s\n")
+	assert.Contains(t, log, "[10:01:00.000] Warning: the competitor(1) lap 2 took +00:00:00.000\n")
+	assert.Contains(t, service.GetFinalReport(), "0/0 (check timing)")
+}
+
+func TestProcessEvent_PenaltySpeedLimits(t *testing.T) {
+	config := testutil.Config()
+	config.PenaltyLen = 150
+	config.SpeedLimits = &domain.SpeedLimits{Penalty: domain.SpeedRange{Min: 2, Max: 8}}
+
+	service := NewCompetitionService(config)
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:04:00.000", domain.EventOnFiringRange, 1, "1"),
+		testutil.Incoming("10:04:30.000", domain.EventLeftFiringRange, 1, ""),
+		// Five misses are 750 m, skied at 2.5 m/s
+		testutil.Incoming("10:04:40.000", domain.EventEnteredPenaltyLaps, 1, ""),
+		testutil.Incoming("10:09:40.000", domain.EventLeftPenaltyLaps, 1, ""),
+		testutil.Incoming("10:20:00.000", domain.EventEndedMainLap, 1, ""),
+	)
+	assert.Empty(t, service.competitors[1].Warnings)
+}
+
+func TestSetLanguage(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	require.NoError(t, service.SetLanguage("de"))
+	assert.Equal(t, "de", service.Printer().Language())
+	service.SetAthletes(domain.AthleteRegistry{2: {CompetitorID: 2, Name: "Jonas Keller"}})
+
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 2, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:01:00.000", domain.EventEndedMainLap, 1, ""),
+	)
+	assert.Equal(t, []string{
+		"[09:00:00.000] Teilnehmer(1) hat sich angemeldet",
+		"[09:00:00.000] Teilnehmer(2, Jonas Keller) hat sich angemeldet",
+		"[09:30:00.000] Die Startzeit für Teilnehmer(1) wurde ausgelost: 10:00:00.000",
+		"[10:00:00.000] Teilnehmer(1) ist gestartet",
+		"[10:01:00.000] Teilnehmer(1) hat die Runde beendet",
+		"[10:01:00.000] Warnung: Teilnehmer(1): Runde 1 mit 50.000 m/s ist unplausibel, erwartet 1.0-12.0 m/s",
+		"[10:01:00.000] Teilnehmer(1) ist im Ziel",
+	}, service.GetLogEntries())
+
+	err := service.SetLanguage("xx")
+	assert.ErrorIs(t, err, i18n.ErrUnknownLanguage)
+	assert.Equal(t, "de", service.Printer().Language(), "the language is kept")
+}
+
 func TestFormatDuration(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -196,7 +316,7 @@ func TestFormatDuration(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := formatDuration(tt.duration)
+			result := FormatDuration(tt.duration)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -237,3 +357,30 @@ func TestGetStatusString(t *testing.T) {
 		})
 	}
 }
+
+func TestReset(t *testing.T) {
+	config := &domain.Config{
+		Laps:        2,
+		LapLen:      3500,
+		PenaltyLen:  150,
+		FiringLines: 2,
+		Start:       "10:00:00.000",
+		StartDelta:  "00:01:30.000",
+	}
+
+	service := NewCompetitionService(config)
+	athletes := domain.AthleteRegistry{1: {CompetitorID: 1, Name: "Ingrid Solberg"}}
+	service.SetAthletes(athletes)
+
+	registerTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	registerEvent := domain.NewEvent(registerTime, domain.EventTypeIncoming, int(domain.EventRegistered), 1, "")
+	err := service.ProcessEvent(registerEvent)
+	assert.NoError(t, err)
+
+	service.Reset()
+	assert.Empty(t, service.competitors)
+	assert.Empty(t, service.events)
+	assert.Empty(t, service.log)
+	assert.Equal(t, config, service.config)
+	assert.Equal(t, athletes, service.athletes)
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// ResultChange describes how the result of one competitor differs between two runs.
+// Before or After is nil when the competitor only exists in one of them.
+type ResultChange struct {
+	CompetitorID int
+	Name         string
+	Before       *Result
+	After        *Result
+}
+
+// RankChanged reports whether the competitor moved in the ranking
+func (c *ResultChange) RankChanged() bool {
+	return c.Before != nil && c.After != nil && c.Before.Rank != c.After.Rank
+}
+
+// TimeChanged reports whether the race time changed. Only finished runs have a race
+// time; a change of status is reported by StatusChanged.
+func (c *ResultChange) TimeChanged() bool {
+	return c.Before != nil && c.After != nil &&
+		c.Before.Status == domain.StatusFinished && c.After.Status == domain.StatusFinished &&
+		c.Before.Time != c.After.Time
+}
+
+// StatusChanged reports whether the result status changed
+func (c *ResultChange) StatusChanged() bool {
+	return c.Before != nil && c.After != nil && c.Before.Status != c.After.Status
+}
+
+// ShootingChanged reports whether hits or range visits changed
+func (c *ResultChange) ShootingChanged() bool {
+	return c.Before != nil && c.After != nil && shootingSummary(c.Before.Competitor) != shootingSummary(c.After.Competitor)
+}
+
+// Changed reports whether anything relevant for the published results differs
+func (c *ResultChange) Changed() bool {
+	return c.Before == nil || c.After == nil ||
+		c.RankChanged() || c.TimeChanged() || c.StatusChanged() || c.ShootingChanged()
+}
+
+// String describes the change in a single line
+func (c *ResultChange) String() string {
+	label := fmt.Sprintf("competitor(%d)", c.CompetitorID)
+	if c.Name != "" {
+		label = fmt.Sprintf("competitor(%d, %s)", c.CompetitorID, c.Name)
+	}
+	switch {
+	case c.Before == nil:
+		return fmt.Sprintf("%s: added, %s", label, describeResult(c.After))
+	case c.After == nil:
+		return fmt.Sprintf("%s: removed, was %s", label, describeResult(c.Before))
+	}
+
+	parts := make([]string, 0, 4)
+	if c.RankChanged() {
+		parts = append(parts, fmt.Sprintf("rank %s -> %s", formatRank(c.Before.Rank), formatRank(c.After.Rank)))
+	}
+	if c.StatusChanged() {
+		parts = append(parts, fmt.Sprintf("status %s -> %s", getStatusString(c.Before.Status), getStatusString(c.After.Status)))
+	}
+	if c.TimeChanged() {
+		parts = append(parts, fmt.Sprintf("time %s -> %s (%s)",
+			FormatDuration(c.Before.Time), FormatDuration(c.After.Time), formatDelta(c.After.Time-c.Before.Time)))
+	}
+	if c.ShootingChanged() {
+		parts = append(parts, fmt.Sprintf("shooting %s -> %s",
+			shootingSummary(c.Before.Competitor), shootingSummary(c.After.Competitor)))
+	}
+	return label + ": " + strings.Join(parts, "; ")
+}
+
+// DiffResults compares the results of two competitions and returns the changed
+// competitors, ordered by their rank in the second one
+func DiffResults(before, after *CompetitionService) []*ResultChange {
+	changes := make(map[int]*ResultChange)
+	for _, result := range before.GetResults() {
+		changes[result.Competitor.ID] = &ResultChange{
+			CompetitorID: result.Competitor.ID,
+			Name:         result.Competitor.Name(),
+			Before:       result,
+		}
+	}
+
+	order := make(map[int]int)
+	for i, result := range after.GetResults() {
+		order[result.Competitor.ID] = i
+		change, exists := changes[result.Competitor.ID]
+		if !exists {
+			change = &ResultChange{CompetitorID: result.Competitor.ID}
+			changes[result.Competitor.ID] = change
+		}
+		change.After = result
+		if name := result.Competitor.Name(); name != "" {
+			change.Name = name
+		}
+	}
+
+	diff := make([]*ResultChange, 0)
+	for _, change := range changes {
+		if change.Changed() {
+			diff = append(diff, change)
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		a, b := diff[i], diff[j]
+		if (a.After == nil) != (b.After == nil) {
+			return b.After == nil
+		}
+		if a.After != nil && order[a.CompetitorID] != order[b.CompetitorID] {
+			return order[a.CompetitorID] < order[b.CompetitorID]
+		}
+		return a.CompetitorID < b.CompetitorID
+	})
+	return diff
+}
+
+// FormatDiff renders result changes one per line
+func FormatDiff(changes []*ResultChange) string {
+	if len(changes) == 0 {
+		return "No result changes\n"
+	}
+	report := ""
+	for _, change := range changes {
+		report += change.String() + "\n"
+	}
+	return report
+}
+
+func describeResult(result *Result) string {
+	if result.Rank == 0 {
+		return getStatusString(result.Status)
+	}
+	return fmt.Sprintf("rank %d, %s", result.Rank, FormatDuration(result.Time))
+}
+
+func formatRank(rank int) string {
+	if rank == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", rank)
+}
+
+func formatDelta(d time.Duration) string {
+	if d < 0 {
+		return "-" + FormatDuration(-d)
+	}
+	return "+" + FormatDuration(d)
+}
+
+// shootingSummary renders hits per range visit and the total, e.g. "5+4 (9/10)"
+func shootingSummary(competitor *domain.Competitor) string {
+	visits := make([]string, 0, len(competitor.Ranges))
+	for _, visit := range competitor.Ranges {
+		visits = append(visits, fmt.Sprintf("%d", visit.Hits))
+	}
+	return fmt.Sprintf("%s (%d/%d)", strings.Join(visits, "+"), competitor.Hits, len(competitor.Ranges)*domain.TargetsPerRange)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffResults(t *testing.T) {
+	before := NewCompetitionService(testutil.Config())
+	processAll(t, before, raceEvents(1, "10:00:00.000", "10:12:00.000")...)
+	processAll(t, before, raceEvents(2, "10:01:30.000", "10:14:00.000")...)
+	processAll(t, before, raceEvents(3, "10:03:00.000", "10:16:00.000")...)
+	processAll(t, before, raceEvents(4, "10:04:30.000", "10:18:30.000")...)
+
+	// A corrected finish time for competitor 2, competitor 3 is removed
+	// and competitor 5 added as a non-starter
+	after := NewCompetitionService(testutil.Config())
+	processAll(t, after, raceEvents(1, "10:00:00.000", "10:12:00.000")...)
+	processAll(t, after, raceEvents(2, "10:01:30.000", "10:11:30.000")...)
+	processAll(t, after, raceEvents(4, "10:04:30.000", "10:18:30.000")...)
+	processAll(t, after, testutil.Incoming("09:00:00.000", domain.EventRegistered, 5, ""))
+
+	changes := DiffResults(before, after)
+	require.Len(t, changes, 5)
+
+	assert.Equal(t, 2, changes[0].CompetitorID)
+	assert.True(t, changes[0].RankChanged())
+	assert.True(t, changes[0].TimeChanged())
+	assert.False(t, changes[0].StatusChanged())
+	assert.Equal(t, "competitor(2): rank 2 -> 1; time 00:12:30.000 -> 00:10:00.000 (-00:02:30.000)", changes[0].String())
+
+	assert.Equal(t, 1, changes[1].CompetitorID)
+	assert.Equal(t, "competitor(1): rank 1 -> 2", changes[1].String())
+
+	// Competitor 4 moves up only because competitor 3 was removed
+	assert.Equal(t, 4, changes[2].CompetitorID)
+	assert.True(t, changes[2].RankChanged())
+	assert.False(t, changes[2].TimeChanged())
+
+	assert.Equal(t, "competitor(5): added, NotStarted", changes[3].String())
+	assert.Equal(t, "competitor(3): removed, was rank 3, 00:13:00.000", changes[4].String())
+}
+
+func TestDiffResults_StatusAndShooting(t *testing.T) {
+	shooting := func(hits int) []*domain.Event {
+		events := []*domain.Event{
+			testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+			testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+			testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+			testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1"),
+		}
+		for target := 1; target <= hits; target++ {
+			events = append(events, testutil.Incoming("10:05:10.000", domain.EventTargetHit, 1, strconv.Itoa(target)))
+		}
+		return append(events, testutil.Incoming("10:05:30.000", domain.EventLeftFiringRange, 1, ""))
+	}
+
+	before := NewCompetitionService(testutil.Config())
+	processAll(t, before, shooting(5)...)
+	processAll(t, before, testutil.Incoming("10:10:00.000", domain.EventEndedMainLap, 1, ""))
+
+	after := NewCompetitionService(testutil.Config())
+	processAll(t, after, shooting(4)...)
+	processAll(t, after, testutil.Incoming("10:08:00.000", domain.EventCannotContinue, 1, "Broken ski"))
+
+	changes := DiffResults(before, after)
+	require.Len(t, changes, 1)
+	assert.True(t, changes[0].StatusChanged())
+	assert.True(t, changes[0].ShootingChanged())
+	// A run that did not finish has no race time to compare
+	assert.False(t, changes[0].TimeChanged())
+	assert.Equal(t, "competitor(1): rank 1 -> -; status Finished -> NotFinished; shooting 5 (5/5) -> 4 (4/5)", changes[0].String())
+}
+
+func TestDiffResults_NoChanges(t *testing.T) {
+	before := NewCompetitionService(testutil.Config())
+	processAll(t, before, raceEvents(1, "10:00:00.000", "10:12:00.000")...)
+	after := NewCompetitionService(testutil.Config())
+	processAll(t, after, raceEvents(1, "10:00:00.000", "10:12:00.000")...)
+
+	changes := DiffResults(before, after)
+	assert.Empty(t, changes)
+	assert.Equal(t, "No result changes\n", FormatDiff(changes))
+}
+
+func TestFormatDelta(t *testing.T) {
+	assert.Equal(t, "+00:00:01.500", formatDelta(1500*time.Millisecond))
+	assert.Equal(t, "-00:01:00.000", formatDelta(-time.Minute))
+}
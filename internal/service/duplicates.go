@@ -0,0 +1,60 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// isDuplicate reports whether an event with the same identity was already applied
+func (s *CompetitionService) isDuplicate(event *domain.Event) bool {
+	return s.seen[event.Key()]
+}
+
+// nearDuplicate returns the applied event that the given one repeats within the
+// duplicate window, nil if there is none
+func (s *CompetitionService) nearDuplicate(event *domain.Event) *domain.Event {
+	window := s.config.DuplicateWindow()
+	if window <= 0 {
+		return nil
+	}
+	previous, ok := s.recent[similarKey(event)]
+	if !ok {
+		return nil
+	}
+	delta := event.Time.Sub(previous.Time)
+	if delta < 0 {
+		delta = -delta
+	}
+	if delta > window {
+		return nil
+	}
+	return previous
+}
+
+// remember records an applied event for duplicate detection
+func (s *CompetitionService) remember(event *domain.Event) {
+	s.seen[event.Key()] = true
+	s.recent[similarKey(event)] = event
+}
+
+// warnNearDuplicate flags an event repeating a recent one, usually a retransmission
+// with a corrected time
+func (s *CompetitionService) warnNearDuplicate(event, previous *domain.Event) {
+	competitor, ok := s.competitors[event.CompetitorID]
+	if !ok {
+		return
+	}
+	// Signed, a late arrival can repeat an event with a later time
+	s.warn(event, competitor, s.printer.Sprintf("warning.repeated",
+		event.EventID, formatDelta(event.Time.Sub(previous.Time)), previous.Time.Format("15:04:05.000")))
+}
+
+// GetDuplicates returns the exact duplicates that were ignored, in arrival order
+func (s *CompetitionService) GetDuplicates() []*domain.Event {
+	return s.duplicates
+}
+
+func similarKey(event *domain.Event) string {
+	return fmt.Sprintf("%d %d %s", event.EventID, event.CompetitorID, event.ExtraParams)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessEvent_ExactDuplicateIgnored(t *testing.T) {
+	config := testutil.Config()
+	config.Laps = 2
+	config.LapLen = 3000
+
+	service := NewCompetitionService(config)
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:10:00.000", domain.EventEndedMainLap, 1, ""),
+		// Resent after a connection hiccup, it must not finish the competitor
+		testutil.Incoming("10:10:00.000", domain.EventEndedMainLap, 1, ""),
+	)
+
+	competitor := service.competitors[1]
+	assert.Len(t, competitor.Laps, 1)
+	assert.Equal(t, domain.StatusRacing, competitor.Status)
+	assert.Empty(t, competitor.Warnings)
+	require.Len(t, service.GetDuplicates(), 1)
+	assert.Equal(t, "[10:10:00.000] 10 1", service.GetDuplicates()[0].String())
+	assert.Len(t, service.GetLogEntries(), 4)
+}
+
+func TestProcessEvent_DuplicateRegistrationKeepsState(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+	)
+	assert.False(t, service.competitors[1].PlannedStart.IsZero())
+}
+
+func TestProcessEvent_SequenceNumbers(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	first := testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, "")
+	first.Seq = 1
+	require.NoError(t, service.ProcessEvent(first))
+
+	// The sequence number identifies the event even when the time was corrected
+	resent := testutil.Incoming("09:00:00.100", domain.EventRegistered, 1, "")
+	resent.Seq = 1
+	require.NoError(t, service.ProcessEvent(resent))
+	assert.Len(t, service.GetDuplicates(), 1)
+	assert.Len(t, service.GetLogEntries(), 1)
+}
+
+func TestProcessEvent_RejectDuplicates(t *testing.T) {
+	config := testutil.Config()
+	config.Duplicates = &domain.DuplicateRules{Reject: true}
+
+	service := NewCompetitionService(config)
+	processAll(t, service, testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""))
+	err := service.ProcessEvent(testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""))
+	assert.ErrorIs(t, err, domain.ErrDuplicateEvent)
+	assert.Empty(t, service.GetDuplicates())
+}
+
+func TestProcessEvent_NearDuplicate(t *testing.T) {
+	tests := []struct {
+		name   string
+		window string
+		second string
+		warned bool
+	}{
+		{name: "within the default window", second: "10:00:05.400", warned: true},
+		{name: "outside the default window", second: "10:00:06.500", warned: false},
+		{name: "within a configured window", window: "00:00:05.000", second: "10:00:09.000", warned: true},
+		{name: "disabled", window: "00:00:00.000", second: "10:00:05.100", warned: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testutil.Config()
+			if tt.window != "" {
+				config.Duplicates = &domain.DuplicateRules{Window: tt.window}
+			}
+			service := NewCompetitionService(config)
+			processAll(t, service,
+				testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+				testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+				testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+				testutil.Incoming("10:00:05.000", domain.EventOnFiringRange, 1, "1"),
+				testutil.Incoming("10:00:05.000", domain.EventTargetHit, 1, "1"),
+				testutil.Incoming(tt.second, domain.EventTargetHit, 1, "1"),
+			)
+
+			warnings := service.competitors[1].Warnings
+			if !tt.warned {
+				assert.Empty(t, warnings)
+				return
+			}
+			require.Len(t, warnings, 1)
+			assert.Contains(t, warnings[0], "repeated event 6")
+			assert.Contains(t, service.GetEventLog(), "Warning: the competitor(1) repeated event 6")
+		})
+	}
+}
+
+func TestReset_ForgetsDuplicates(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+	)
+	service.Reset()
+	processAll(t, service, testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""))
+	assert.Len(t, service.GetCompetitors(), 1)
+	assert.Empty(t, service.GetDuplicates())
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidClock = errors.New("invalid time of day")
+
+// LogKind tells event log entries about events apart from warnings
+type LogKind string
+
+const (
+	LogEvent   LogKind = "event"
+	LogWarning LogKind = "warning"
+)
+
+// LogEntry is an event log line together with what it is about
+type LogEntry struct {
+	Time         time.Time
+	CompetitorID int
+	// EventID is the incoming event, or the outgoing one for a finish
+	EventID int
+	Kind    LogKind
+	// FiringLine is set for range entries, 0 otherwise
+	FiringLine int
+	Message    string
+}
+
+// MarshalJSON writes the time as hh:mm:ss.sss like the events file
+func (e LogEntry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Time         string  `json:"time"`
+		CompetitorID int     `json:"competitorId"`
+		EventID      int     `json:"eventId"`
+		Kind         LogKind `json:"kind"`
+		FiringLine   int     `json:"firingLine,omitempty"`
+		Message      string  `json:"message"`
+	}{e.Time.Format("15:04:05.000"), e.CompetitorID, e.EventID, e.Kind, e.FiringLine, e.Message})
+}
+
+// LogFilter selects event log entries; empty fields match everything. The time range
+// includes both ends.
+type LogFilter struct {
+	Competitors []int
+	EventIDs    []int
+	FiringLines []int
+	Kind        LogKind
+	From        time.Time
+	To          time.Time
+}
+
+// Match reports whether the entry passes the filter
+func (f LogFilter) Match(entry LogEntry) bool {
+	if len(f.Competitors) > 0 && !containsInt(f.Competitors, entry.CompetitorID) {
+		return false
+	}
+	if len(f.EventIDs) > 0 && !containsInt(f.EventIDs, entry.EventID) {
+		return false
+	}
+	if len(f.FiringLines) > 0 && !containsInt(f.FiringLines, entry.FiringLine) {
+		return false
+	}
+	if f.Kind != "" && f.Kind != entry.Kind {
+		return false
+	}
+	if !f.From.IsZero() && entry.Time.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && entry.Time.After(f.To) {
+		return false
+	}
+	return true
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFilteredLog returns the event log entries passing the filter, in log order
+func (s *CompetitionService) GetFilteredLog(filter LogFilter) []LogEntry {
+	entries := make([]LogEntry, 0)
+	for i, message := range s.log {
+		meta := s.logMeta[i]
+		entry := LogEntry{
+			Time:         meta.time,
+			CompetitorID: meta.competitorID,
+			EventID:      meta.eventID,
+			Kind:         meta.kind,
+			FiringLine:   meta.firingLine,
+			Message:      message,
+		}
+		if filter.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// ParseClock parses a time of day given as hh:mm, hh:mm:ss or hh:mm:ss.sss
+func ParseClock(value string) (time.Time, error) {
+	for _, layout := range []string{"15:04:05.000", "15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidClock, value)
+}
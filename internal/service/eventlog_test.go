@@ -0,0 +1,108 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLogCompetition runs a one lap race in which 1 shoots on line 1 and finishes,
+// 2 shoots on line 2 and gives up
+func newLogCompetition(t *testing.T) *CompetitionService {
+	t.Helper()
+	config := testutil.Config()
+	config.FiringLines = 2
+	service := NewCompetitionService(config)
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 2, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 2, "10:01:30.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:01:30.000", domain.EventStarted, 2, ""),
+		testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1"),
+		testutil.Incoming("10:05:10.000", domain.EventTargetHit, 1, "1"),
+		testutil.Incoming("10:05:20.000", domain.EventTargetHit, 1, "2"),
+		testutil.Incoming("10:05:40.000", domain.EventLeftFiringRange, 1, ""),
+		testutil.Incoming("10:06:30.000", domain.EventOnFiringRange, 2, "2"),
+		testutil.Incoming("10:06:40.000", domain.EventTargetHit, 2, "1"),
+		testutil.Incoming("10:07:00.000", domain.EventLeftFiringRange, 2, ""),
+		testutil.Incoming("10:08:00.000", domain.EventCannotContinue, 2, "Cold"),
+		testutil.Incoming("10:12:00.000", domain.EventEndedMainLap, 1, ""),
+	)
+	return service
+}
+
+func TestGetFilteredLog(t *testing.T) {
+	service := newLogCompetition(t)
+
+	all := service.GetFilteredLog(LogFilter{})
+	require.Len(t, all, len(service.GetLogEntries()))
+	for i, entry := range all {
+		assert.Equal(t, service.GetLogEntries()[i], entry.Message)
+	}
+
+	finish := all[len(all)-1]
+	assert.Equal(t, int(domain.EventFinished), finish.EventID)
+	assert.Equal(t, 1, finish.CompetitorID)
+	assert.Equal(t, LogEvent, finish.Kind)
+
+	from, err := ParseClock("10:05")
+	require.NoError(t, err)
+	to, err := ParseClock("10:06:40")
+	require.NoError(t, err)
+	entries := service.GetFilteredLog(LogFilter{Competitors: []int{1}, From: from, To: to})
+	require.Len(t, entries, 4)
+	assert.Equal(t, "[10:05:00.000] The competitor(1) is on the firing range(1)", entries[0].Message)
+	assert.Equal(t, 1, entries[3].FiringLine, "the exit is on the line of the visit")
+
+	entries = service.GetFilteredLog(LogFilter{FiringLines: []int{2}})
+	require.Len(t, entries, 3)
+	assert.Equal(t, 2, entries[0].CompetitorID)
+
+	entries = service.GetFilteredLog(LogFilter{EventIDs: []int{int(domain.EventTargetHit)}, To: to})
+	require.Len(t, entries, 3, "the end of the range is included")
+
+	entries = service.GetFilteredLog(LogFilter{Competitors: []int{2}, Kind: LogWarning})
+	assert.Empty(t, entries)
+}
+
+func TestGetFilteredLog_Warnings(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:01:00.000", domain.EventEndedMainLap, 1, ""),
+	)
+
+	entries := service.GetFilteredLog(LogFilter{Kind: LogWarning})
+	require.Len(t, entries, 1)
+	assert.Equal(t, int(domain.EventEndedMainLap), entries[0].EventID)
+	assert.Contains(t, entries[0].Message, "Warning")
+}
+
+func TestLogEntry_MarshalJSON(t *testing.T) {
+	entries := newLogCompetition(t).GetFilteredLog(LogFilter{EventIDs: []int{int(domain.EventOnFiringRange)}})
+	require.NotEmpty(t, entries)
+
+	data, err := json.Marshal(entries[0])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"time": "10:05:00.000", "competitorId": 1, "eventId": 5, "kind": "event",
+		"firingLine": 1, "message": "[10:05:00.000] The competitor(1) is on the firing range(1)"}`, string(data))
+}
+
+func TestParseClock(t *testing.T) {
+	for _, value := range []string{"10:20", "10:20:00", "10:20:00.000"} {
+		clock, err := ParseClock(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, parseTime("10:20:00.000"), clock)
+	}
+
+	_, err := ParseClock("10h20")
+	assert.ErrorIs(t, err, ErrInvalidClock)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// ExportedResult is a result line of the JSON results export
+type ExportedResult struct {
+	Rank         int    `json:"rank,omitempty"`
+	CategoryRank int    `json:"categoryRank,omitempty"`
+	CompetitorID int    `json:"competitorId"`
+	AthleteID    string `json:"athleteId,omitempty"`
+	Bib          int    `json:"bib"`
+	Name         string `json:"name,omitempty"`
+	Category     string `json:"category,omitempty"`
+	Status       string `json:"status"`
+	Time         string `json:"time,omitempty"`
+	Behind       string `json:"behind,omitempty"`
+	Hits         int    `json:"hits"`
+	Shots        int    `json:"shots"`
+	Comment      string `json:"comment,omitempty"`
+}
+
+// ResultsExport is the machine readable form of the results, used to carry them
+// into later races such as a pursuit or a cup
+type ResultsExport struct {
+	Name    string           `json:"name,omitempty"`
+	Date    string           `json:"date,omitempty"`
+	Results []ExportedResult `json:"results"`
+}
+
+// ExportResults returns the results in the order of GetResults
+func (s *CompetitionService) ExportResults() *ResultsExport {
+	categoryRanks := make(map[int]int)
+	categories := make(map[int]string)
+	for _, group := range s.GetCategoryResults() {
+		for _, result := range group.Results {
+			categoryRanks[result.Competitor.ID] = result.Rank
+			categories[result.Competitor.ID] = group.Category
+		}
+	}
+
+	export := &ResultsExport{
+		Name:    s.config.Name,
+		Date:    s.config.Date,
+		Results: make([]ExportedResult, 0, len(s.competitors)),
+	}
+	for _, result := range s.GetResults() {
+		competitor := result.Competitor
+		exported := ExportedResult{
+			Rank:         result.Rank,
+			CategoryRank: categoryRanks[competitor.ID],
+			CompetitorID: competitor.ID,
+			Bib:          competitor.ID,
+			Name:         competitor.Name(),
+			Category:     categories[competitor.ID],
+			Status:       getStatusString(result.Status),
+			Hits:         competitor.Hits,
+			Shots:        len(competitor.Ranges) * domain.TargetsPerRange,
+			Comment:      competitor.Comment,
+		}
+		if athlete := competitor.Athlete; athlete != nil {
+			exported.AthleteID = athlete.ID
+			exported.Bib = athlete.Bib
+		}
+		if result.Rank > 0 {
+			exported.Time = FormatDuration(result.Time)
+			exported.Behind = "+" + FormatDuration(result.Behind)
+		}
+		export.Results = append(export.Results, exported)
+	}
+	return export
+}
+
+var durationRegex = regexp.MustCompile(`^(\d{2,}):(\d{2}):(\d{2})\.(\d{3})$`)
+
+// ParseDuration parses a duration in the hh:mm:ss.sss format of FormatDuration
+func ParseDuration(value string) (time.Duration, error) {
+	matches := durationRegex.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	parts := make([]int, 4)
+	for i := range parts {
+		parts[i], _ = strconv.Atoi(matches[i+1])
+	}
+	if parts[1] > 59 || parts[2] > 59 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return time.Duration(parts[0])*time.Hour + time.Duration(parts[1])*time.Minute +
+		time.Duration(parts[2])*time.Second + time.Duration(parts[3])*time.Millisecond, nil
+}
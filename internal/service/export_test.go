@@ -0,0 +1,51 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportResults(t *testing.T) {
+	export := newPositionsCompetition(t).ExportResults()
+	require.Len(t, export.Results, 4)
+
+	first := export.Results[0]
+	assert.Equal(t, 1, first.Rank)
+	assert.Equal(t, 3, first.CompetitorID)
+	assert.Equal(t, 3, first.Bib)
+	assert.Equal(t, "Finished", first.Status)
+	assert.Equal(t, "00:30:00.000", first.Time)
+	assert.Equal(t, "+00:00:00.000", first.Behind)
+
+	third := export.Results[2]
+	assert.Equal(t, 1, third.CompetitorID)
+	assert.Equal(t, "+00:03:00.000", third.Behind)
+
+	last := export.Results[3]
+	assert.Equal(t, 0, last.Rank)
+	assert.Equal(t, "NotFinished", last.Status)
+	assert.Empty(t, last.Time)
+	assert.Equal(t, "Injury", last.Comment)
+
+	data, err := json.Marshal(export)
+	require.NoError(t, err)
+	var decoded ResultsExport
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *export, decoded)
+}
+
+func TestParseDuration(t *testing.T) {
+	d, err := ParseDuration("01:02:03.456")
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour+2*time.Minute+3*time.Second+456*time.Millisecond, d)
+	assert.Equal(t, "01:02:03.456", FormatDuration(d))
+
+	for _, value := range []string{"", "1:02:03.456", "00:60:00.000", "00:00:00", "-00:00:01.000"} {
+		_, err := ParseDuration(value)
+		assert.Error(t, err, value)
+	}
+}
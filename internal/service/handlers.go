@@ -0,0 +1,74 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// EventHandler observes the events processed by a competition. Handlers run
+// synchronously inside ProcessEvent in the order they were registered.
+type EventHandler interface {
+	// HandleBefore is called for an incoming event before it is applied.
+	// Returning an error rejects the event: it is neither applied nor logged,
+	// and the handlers registered later are not called.
+	HandleBefore(s *CompetitionService, event *domain.Event) error
+	// HandleAfter is called once the incoming event is applied, then for each
+	// outgoing event it produced, in the order they were produced.
+	HandleAfter(s *CompetitionService, event *domain.Event)
+}
+
+// BeforeFunc adapts a function to an EventHandler that only checks incoming events
+type BeforeFunc func(s *CompetitionService, event *domain.Event) error
+
+func (f BeforeFunc) HandleBefore(s *CompetitionService, event *domain.Event) error {
+	return f(s, event)
+}
+
+func (f BeforeFunc) HandleAfter(*CompetitionService, *domain.Event) {}
+
+// AfterFunc adapts a function to an EventHandler that only observes applied events
+type AfterFunc func(s *CompetitionService, event *domain.Event)
+
+func (f AfterFunc) HandleBefore(*CompetitionService, *domain.Event) error {
+	return nil
+}
+
+func (f AfterFunc) HandleAfter(s *CompetitionService, event *domain.Event) {
+	f(s, event)
+}
+
+// AddHandler registers a handler after the already registered ones.
+// Handlers are kept by Reset.
+func (s *CompetitionService) AddHandler(handler EventHandler) {
+	s.handlers = append(s.handlers, handler)
+}
+
+// handleBefore runs the pre-hooks and wraps the first rejection
+func (s *CompetitionService) handleBefore(event *domain.Event) error {
+	if s.replaying {
+		return nil
+	}
+	for _, handler := range s.handlers {
+		if err := handler.HandleBefore(s, event); err != nil {
+			return fmt.Errorf("event rejected: %w", err)
+		}
+	}
+	return nil
+}
+
+// handleAfter runs the post-hooks for an applied incoming event and the outgoing
+// events it produced
+func (s *CompetitionService) handleAfter(event *domain.Event, outgoing []*domain.Event) {
+	if len(s.handlers) == 0 || s.replaying {
+		return
+	}
+	for _, handler := range s.handlers {
+		handler.HandleAfter(s, event)
+	}
+	for _, out := range outgoing {
+		for _, handler := range s.handlers {
+			handler.HandleAfter(s, out)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder logs every hook call as "name:phase:eventID"
+type recorder struct {
+	name  string
+	calls *[]string
+}
+
+func (r recorder) HandleBefore(_ *CompetitionService, event *domain.Event) error {
+	*r.calls = append(*r.calls, fmt.Sprintf("%s:before:%d", r.name, event.EventID))
+	return nil
+}
+
+func (r recorder) HandleAfter(_ *CompetitionService, event *domain.Event) {
+	*r.calls = append(*r.calls, fmt.Sprintf("%s:after:%d", r.name, event.EventID))
+}
+
+func TestAddHandler_Order(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	calls := make([]string, 0)
+	service.AddHandler(recorder{"first", &calls})
+	service.AddHandler(recorder{"second", &calls})
+
+	processAll(t, service, raceEvents(1, "10:00:00.000", "10:15:00.000")...)
+	calls = calls[len(calls)-6:]
+
+	// The finishing lap is followed by the outgoing finish event
+	assert.Equal(t, []string{
+		"first:before:10",
+		"second:before:10",
+		"first:after:10",
+		"second:after:10",
+		"first:after:33",
+		"second:after:33",
+	}, calls)
+}
+
+func TestAddHandler_Reject(t *testing.T) {
+	errLateEntry := errors.New("late entry")
+	service := NewCompetitionService(testutil.Config())
+	calls := make([]string, 0)
+	service.AddHandler(BeforeFunc(func(s *CompetitionService, event *domain.Event) error {
+		if event.EventID == int(domain.EventRegistered) && event.Time.Hour() >= 10 {
+			return errLateEntry
+		}
+		return nil
+	}))
+	service.AddHandler(recorder{"observer", &calls})
+
+	processAll(t, service, testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""))
+	err := service.ProcessEvent(testutil.Incoming("10:00:00.000", domain.EventRegistered, 2, ""))
+	assert.ErrorIs(t, err, errLateEntry)
+
+	assert.Len(t, service.GetCompetitors(), 1)
+	assert.Len(t, service.log, 1)
+	assert.Equal(t, []string{"observer:before:1", "observer:after:1"}, calls)
+}
+
+func TestAddHandler_AfterFunc(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	finishers := make([]int, 0)
+	service.AddHandler(AfterFunc(func(s *CompetitionService, event *domain.Event) {
+		if event.Type == domain.EventTypeOutgoing && event.EventID == int(domain.EventFinished) {
+			finishers = append(finishers, event.CompetitorID)
+		}
+	}))
+
+	processAll(t, service, raceEvents(2, "10:00:00.000", "10:15:00.000")...)
+	processAll(t, service, raceEvents(1, "10:01:30.000", "10:17:00.000")...)
+	assert.Equal(t, []int{2, 1}, finishers)
+
+	service.Reset()
+	processAll(t, service, raceEvents(3, "10:00:00.000", "10:15:00.000")...)
+	require.Equal(t, []int{2, 1, 3}, finishers, "handlers are kept by Reset")
+}
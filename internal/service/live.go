@@ -0,0 +1,143 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// Standing represents the live position of a competitor during the race
+type Standing struct {
+	Position   int
+	Competitor *domain.Competitor
+	Laps       int
+	TotalLaps  int
+	Time       time.Duration // since the start at the end of the last completed lap
+	Gap        time.Duration // behind the fastest time at the same lap
+}
+
+// GetStandings returns the live order of the competitors who completed at least one lap.
+// Competitors further in the race come first; on the same lap the faster time wins.
+func (s *CompetitionService) GetStandings() []*Standing {
+	standings := make([]*Standing, 0, len(s.competitors))
+	for _, competitor := range s.competitors {
+		if len(competitor.Laps) == 0 || competitor.Status == domain.StatusNotFinished || competitor.Status == domain.StatusDisqualified {
+			continue
+		}
+		standings = append(standings, &Standing{
+			Competitor: competitor,
+			Laps:       len(competitor.Laps),
+			TotalLaps:  s.rulesFor(competitor).Laps,
+			Time:       lapTimeSinceStart(competitor, len(competitor.Laps)),
+		})
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.Laps != b.Laps {
+			return a.Laps > b.Laps
+		}
+		if a.Time != b.Time {
+			return a.Time < b.Time
+		}
+		return a.Competitor.ID < b.Competitor.ID
+	})
+
+	best := make(map[int]time.Duration)
+	for _, competitor := range s.competitors {
+		for lap := range competitor.Laps {
+			lapTime := lapTimeSinceStart(competitor, lap+1)
+			if current, ok := best[lap+1]; !ok || lapTime < current {
+				best[lap+1] = lapTime
+			}
+		}
+	}
+	for i, standing := range standings {
+		standing.Position = i + 1
+		standing.Gap = standing.Time - best[standing.Laps]
+	}
+	return standings
+}
+
+// GetCompetitors returns all registered competitors ordered by ID
+func (s *CompetitionService) GetCompetitors() []*domain.Competitor {
+	competitors := make([]*domain.Competitor, 0, len(s.competitors))
+	for _, competitor := range s.competitors {
+		competitors = append(competitors, competitor)
+	}
+	sort.Slice(competitors, func(i, j int) bool {
+		return competitors[i].ID < competitors[j].ID
+	})
+	return competitors
+}
+
+// GetRecentFinishers returns up to n competitors who finished last, the most recent first
+func (s *CompetitionService) GetRecentFinishers(n int) []*domain.Competitor {
+	finishers := make([]*domain.Competitor, 0)
+	for _, competitor := range s.competitors {
+		if competitor.Status == domain.StatusFinished {
+			finishers = append(finishers, competitor)
+		}
+	}
+	sort.Slice(finishers, func(i, j int) bool {
+		if !finishers[i].FinishTime.Equal(finishers[j].FinishTime) {
+			return finishers[i].FinishTime.After(finishers[j].FinishTime)
+		}
+		return finishers[i].ID < finishers[j].ID
+	})
+	if len(finishers) > n {
+		finishers = finishers[:n]
+	}
+	return finishers
+}
+
+// GetRecentLog returns up to n of the latest event log entries
+func (s *CompetitionService) GetRecentLog(n int) []string {
+	if len(s.log) <= n {
+		return s.log
+	}
+	return s.log[len(s.log)-n:]
+}
+
+// GetLogEntries returns all event log entries
+func (s *CompetitionService) GetLogEntries() []string {
+	return s.log
+}
+
+// GetNewLogEntries returns the event log entries added after the given position, in
+// log order, and the position to pass next time. A late event's entries are inserted
+// in time order, so they are not at the end of the log; the entries a reprocessed
+// competitor logs again unchanged keep their position and are not returned again.
+func (s *CompetitionService) GetNewLogEntries(after int) ([]string, int) {
+	entries := make([]string, 0)
+	for i, meta := range s.logMeta {
+		if meta.seq > after {
+			entries = append(entries, s.log[i])
+		}
+	}
+	return entries, s.logSeq
+}
+
+// GetLastEventTime returns the time of the latest processed event
+func (s *CompetitionService) GetLastEventTime() time.Time {
+	if len(s.events) == 0 {
+		return time.Time{}
+	}
+	return s.events[len(s.events)-1].Time
+}
+
+// lapTimeSinceStart returns the time from the start to the end of the given lap,
+// or the time of the last completed lap if the competitor did not get that far
+func lapTimeSinceStart(competitor *domain.Competitor, lap int) time.Duration {
+	if lap > len(competitor.Laps) {
+		lap = len(competitor.Laps)
+	}
+	return competitor.Laps[lap-1].End.Sub(raceStart(competitor))
+}
+
+// GetEvents returns the applied incoming events and the outgoing events they produced,
+// in the order they were recorded
+func (s *CompetitionService) GetEvents() []*domain.Event {
+	return s.events
+}
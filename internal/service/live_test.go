@@ -0,0 +1,82 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStandings(t *testing.T) {
+	config := testutil.Config()
+	config.Laps = 2
+	service := NewCompetitionService(config)
+
+	for id, start := range map[int]string{1: "10:00:00.000", 2: "10:01:30.000", 3: "10:03:00.000", 4: "10:04:30.000"} {
+		processAll(t, service,
+			testutil.Incoming("09:00:00.000", domain.EventRegistered, id, ""),
+			testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, id, start),
+			testutil.Incoming(start, domain.EventStarted, id, ""),
+		)
+	}
+	processAll(t, service,
+		testutil.Incoming("10:10:00.000", domain.EventEndedMainLap, 1, ""),
+		testutil.Incoming("10:11:00.000", domain.EventEndedMainLap, 2, ""),
+		testutil.Incoming("10:12:00.000", domain.EventEndedMainLap, 3, ""),
+		testutil.Incoming("10:13:00.000", domain.EventCannotContinue, 4, "Fell"),
+		testutil.Incoming("10:19:00.000", domain.EventEndedMainLap, 2, ""),
+	)
+
+	standings := service.GetStandings()
+	require.Len(t, standings, 3)
+
+	// Competitor 2 is a lap ahead, then competitor 3 set the fastest first lap
+	assert.Equal(t, 2, standings[0].Competitor.ID)
+	assert.Equal(t, 2, standings[0].Laps)
+	assert.Equal(t, 2, standings[0].TotalLaps)
+	assert.Equal(t, 17*time.Minute+30*time.Second, standings[0].Time)
+	assert.Equal(t, time.Duration(0), standings[0].Gap)
+
+	assert.Equal(t, 3, standings[1].Competitor.ID)
+	assert.Equal(t, 2, standings[1].Position)
+	assert.Equal(t, 9*time.Minute, standings[1].Time)
+	assert.Equal(t, time.Duration(0), standings[1].Gap)
+
+	assert.Equal(t, 1, standings[2].Competitor.ID)
+	assert.Equal(t, 10*time.Minute, standings[2].Time)
+	assert.Equal(t, time.Minute, standings[2].Gap)
+}
+
+func TestGetRecentFinishers(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	processAll(t, service, raceEvents(1, "10:00:00.000", "10:12:00.000")...)
+	processAll(t, service, raceEvents(2, "10:01:30.000", "10:11:00.000")...)
+	processAll(t, service, raceEvents(3, "10:03:00.000", "10:14:00.000")...)
+
+	finishers := service.GetRecentFinishers(2)
+	require.Len(t, finishers, 2)
+	assert.Equal(t, 3, finishers[0].ID)
+	assert.Equal(t, 1, finishers[1].ID)
+	assert.Len(t, service.GetRecentFinishers(10), 3)
+}
+
+func TestGetRecentLog(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	assert.Empty(t, service.GetRecentLog(3))
+	assert.True(t, service.GetLastEventTime().IsZero())
+
+	processAll(t, service, raceEvents(1, "10:00:00.000", "10:12:00.000")...)
+	recent := service.GetRecentLog(2)
+	require.Len(t, recent, 2)
+	assert.Contains(t, recent[0], "ended the main lap")
+	assert.Contains(t, recent[1], "has finished")
+	assert.Len(t, service.GetRecentLog(10), 5)
+	assert.Equal(t, parseTime("10:12:00.000"), service.GetLastEventTime())
+
+	competitors := service.GetCompetitors()
+	require.Len(t, competitors, 1)
+	assert.Equal(t, 1, competitors[0].ID)
+}
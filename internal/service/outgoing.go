@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// GetOutgoingEvents returns the outgoing events produced so far, in the order they
+// were produced
+func (s *CompetitionService) GetOutgoingEvents() []*domain.Event {
+	outgoing := make([]*domain.Event, 0)
+	for _, event := range s.events {
+		if event.Type == domain.EventTypeOutgoing {
+			outgoing = append(outgoing, event)
+		}
+	}
+	return outgoing
+}
+
+// WriteOutgoingEvents writes the outgoing events one per line in the events file format
+func (s *CompetitionService) WriteOutgoingEvents(w io.Writer) error {
+	for _, event := range s.GetOutgoingEvents() {
+		if _, err := fmt.Fprintln(w, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OutgoingFeed is an event handler writing each outgoing event to a writer as soon as
+// it is produced, in the events file format, so that other tools can follow it
+type OutgoingFeed struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewOutgoingFeed returns a feed writing to w
+func NewOutgoingFeed(w io.Writer) *OutgoingFeed {
+	return &OutgoingFeed{w: w}
+}
+
+func (f *OutgoingFeed) HandleBefore(*CompetitionService, *domain.Event) error {
+	return nil
+}
+
+func (f *OutgoingFeed) HandleAfter(_ *CompetitionService, event *domain.Event) {
+	if event.Type != domain.EventTypeOutgoing {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return
+	}
+	_, f.err = fmt.Fprintln(f.w, event)
+}
+
+// Err returns the first write error; events after it are not written
+func (f *OutgoingFeed) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOutgoingEvents(t *testing.T) {
+	service := newLogCompetition(t)
+
+	outgoing := service.GetOutgoingEvents()
+	require.Len(t, outgoing, 2)
+	assert.Equal(t, "[10:08:00.000] 32 2 Cold", outgoing[0].String())
+	assert.Equal(t, "[10:12:00.000] 33 1", outgoing[1].String())
+
+	var buf bytes.Buffer
+	require.NoError(t, service.WriteOutgoingEvents(&buf))
+	assert.Equal(t, "[10:08:00.000] 32 2 Cold\n[10:12:00.000] 33 1\n", buf.String())
+
+	// The feed reads back with the events file parser
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		event, err := domain.ParseEvent(line)
+		require.NoError(t, err)
+		assert.Equal(t, line, event.String())
+	}
+}
+
+func TestOutgoingFeed(t *testing.T) {
+	var buf bytes.Buffer
+	feed := NewOutgoingFeed(&buf)
+	service := NewCompetitionService(testutil.Config())
+	service.AddHandler(feed)
+
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+	)
+	assert.Empty(t, buf.String(), "incoming events are not fed")
+
+	processAll(t, service, testutil.Incoming("10:12:00.000", domain.EventEndedMainLap, 1, ""))
+	assert.Equal(t, "[10:12:00.000] 33 1\n", buf.String())
+	assert.NoError(t, feed.Err())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestOutgoingFeed_Error(t *testing.T) {
+	feed := NewOutgoingFeed(failingWriter{})
+	service := NewCompetitionService(testutil.Config())
+	service.AddHandler(feed)
+
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:05:00.000", domain.EventCannotContinue, 1, "Cold"),
+	)
+	assert.EqualError(t, feed.Err(), "broken pipe")
+}
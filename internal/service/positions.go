@@ -0,0 +1,222 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// LapPositions holds the position of a competitor in the field at the end of every
+// completed lap, ranked by the cumulative time since the planned start
+type LapPositions struct {
+	Competitor *domain.Competitor
+	Positions  []int           // position at the end of lap i+1
+	Times      []time.Duration // cumulative time at the end of lap i+1
+	Rank       int             // final rank, 0 without a valid finish
+}
+
+// Change returns the number of places gained from the first to the last completed lap
+func (p *LapPositions) Change() int {
+	if len(p.Positions) == 0 {
+		return 0
+	}
+	return p.Positions[0] - p.Positions[len(p.Positions)-1]
+}
+
+// PositionChart is the lap-by-lap order of the field
+type PositionChart []*LapPositions
+
+// GetPositionChart returns the positions of every competitor who completed at least
+// one lap, in the order of the results
+func (s *CompetitionService) GetPositionChart() PositionChart {
+	chart := make(PositionChart, 0, len(s.competitors))
+	byID := make(map[int]*LapPositions)
+	for _, result := range s.GetResults() {
+		if len(result.Competitor.Laps) == 0 {
+			continue
+		}
+		positions := &LapPositions{
+			Competitor: result.Competitor,
+			Positions:  make([]int, len(result.Competitor.Laps)),
+			Times:      make([]time.Duration, len(result.Competitor.Laps)),
+			Rank:       result.Rank,
+		}
+		for lap := range result.Competitor.Laps {
+			positions.Times[lap] = lapTimeSinceStart(result.Competitor, lap+1)
+		}
+		chart = append(chart, positions)
+		byID[result.Competitor.ID] = positions
+	}
+
+	for lap := 0; ; lap++ {
+		field := make([]*LapPositions, 0, len(chart))
+		for _, positions := range chart {
+			if lap < len(positions.Times) {
+				field = append(field, positions)
+			}
+		}
+		if len(field) == 0 {
+			break
+		}
+		sort.SliceStable(field, func(i, j int) bool {
+			if field[i].Times[lap] != field[j].Times[lap] {
+				return field[i].Times[lap] < field[j].Times[lap]
+			}
+			return field[i].Competitor.ID < field[j].Competitor.ID
+		})
+		for i, positions := range field {
+			position := i + 1
+			if i > 0 && positions.Times[lap] == field[i-1].Times[lap] {
+				position = field[i-1].Positions[lap]
+			}
+			byID[positions.Competitor.ID].Positions[lap] = position
+		}
+	}
+	return chart
+}
+
+// Laps returns the highest number of laps completed by any competitor
+func (c PositionChart) Laps() int {
+	laps := 0
+	for _, positions := range c {
+		if len(positions.Positions) > laps {
+			laps = len(positions.Positions)
+		}
+	}
+	return laps
+}
+
+// Gainers returns up to n competitors who gained the most places, the biggest gain first
+func (c PositionChart) Gainers(n int) []*LapPositions {
+	return c.moves(n, func(change int) bool { return change > 0 })
+}
+
+// Losers returns up to n competitors who lost the most places, the biggest loss first
+func (c PositionChart) Losers(n int) []*LapPositions {
+	return c.moves(n, func(change int) bool { return change < 0 })
+}
+
+func (c PositionChart) moves(n int, keep func(change int) bool) []*LapPositions {
+	moves := make([]*LapPositions, 0)
+	for _, positions := range c {
+		if keep(positions.Change()) {
+			moves = append(moves, positions)
+		}
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		return abs(moves[i].Change()) > abs(moves[j].Change())
+	})
+	if len(moves) > n {
+		moves = moves[:n]
+	}
+	return moves
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Summary renders the positions per lap followed by the biggest gainers and losers
+func (c PositionChart) Summary(n int) string {
+	report := "Positions by lap\n"
+	for _, positions := range c {
+		line := positionsLabel(positions.Competitor) + ":"
+		for _, position := range positions.Positions {
+			line += fmt.Sprintf(" %d", position)
+		}
+		report += line + "\n"
+	}
+
+	for _, section := range []struct {
+		title string
+		moves []*LapPositions
+	}{
+		{"Biggest gainers", c.Gainers(n)},
+		{"Biggest losers", c.Losers(n)},
+	} {
+		report += "\n" + section.title + "\n"
+		if len(section.moves) == 0 {
+			report += "  none\n"
+		}
+		for _, positions := range section.moves {
+			report += fmt.Sprintf("  %s: %d -> %d (%+d)\n", positionsLabel(positions.Competitor),
+				positions.Positions[0], positions.Positions[len(positions.Positions)-1], positions.Change())
+		}
+	}
+	return report
+}
+
+func positionsLabel(competitor *domain.Competitor) string {
+	if name := competitor.Name(); name != "" {
+		return fmt.Sprintf("competitor(%d, %s)", competitor.ID, name)
+	}
+	return fmt.Sprintf("competitor(%d)", competitor.ID)
+}
+
+// MarshalJSON renders the chart as a list of competitors with their positions and
+// cumulative times per lap
+func (c PositionChart) MarshalJSON() ([]byte, error) {
+	type entry struct {
+		CompetitorID int      `json:"competitorId"`
+		Name         string   `json:"name,omitempty"`
+		Rank         int      `json:"rank,omitempty"`
+		Positions    []int    `json:"positions"`
+		Times        []string `json:"times"`
+	}
+	entries := make([]entry, 0, len(c))
+	for _, positions := range c {
+		times := make([]string, 0, len(positions.Times))
+		for _, t := range positions.Times {
+			times = append(times, FormatDuration(t))
+		}
+		entries = append(entries, entry{
+			CompetitorID: positions.Competitor.ID,
+			Name:         positions.Competitor.Name(),
+			Rank:         positions.Rank,
+			Positions:    positions.Positions,
+			Times:        times,
+		})
+	}
+	return json.Marshal(entries)
+}
+
+// WriteCSV writes one row per competitor with the position at the end of every lap;
+// laps the competitor did not complete are left empty
+func (c PositionChart) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"competitor", "name", "rank"}
+	for lap := 1; lap <= c.Laps(); lap++ {
+		header = append(header, fmt.Sprintf("lap %d", lap))
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, positions := range c {
+		row := []string{strconv.Itoa(positions.Competitor.ID), positions.Competitor.Name(), ""}
+		if positions.Rank > 0 {
+			row[2] = strconv.Itoa(positions.Rank)
+		}
+		for lap := 0; lap < c.Laps(); lap++ {
+			cell := ""
+			if lap < len(positions.Positions) {
+				cell = strconv.Itoa(positions.Positions[lap])
+			}
+			row = append(row, cell)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPositionsCompetition runs a three lap race in which 3 comes from behind,
+// 1 fades and 4 drops out after the first lap
+func newPositionsCompetition(t *testing.T) *CompetitionService {
+	t.Helper()
+	config := testutil.Config()
+	config.Laps = 3
+	config.LapLen = 3000
+	service := NewCompetitionService(config)
+
+	events := make([]*domain.Event, 0)
+	for id, start := range map[int]string{1: "10:00:00.000", 2: "10:01:00.000", 3: "10:02:00.000", 4: "10:03:00.000"} {
+		events = append(events,
+			testutil.Incoming("09:00:00.000", domain.EventRegistered, id, ""),
+			testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, id, start))
+	}
+	events = append(events,
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:01:00.000", domain.EventStarted, 2, ""),
+		testutil.Incoming("10:02:00.000", domain.EventStarted, 3, ""),
+		testutil.Incoming("10:03:00.000", domain.EventStarted, 4, ""),
+		// Lap 1: 1 10:00, 2 10:30, 3 11:00, 4 11:30
+		testutil.Incoming("10:10:00.000", domain.EventEndedMainLap, 1, ""),
+		testutil.Incoming("10:11:30.000", domain.EventEndedMainLap, 2, ""),
+		testutil.Incoming("10:13:00.000", domain.EventEndedMainLap, 3, ""),
+		testutil.Incoming("10:14:30.000", domain.EventEndedMainLap, 4, ""),
+		testutil.Incoming("10:15:00.000", domain.EventCannotContinue, 4, "Injury"),
+		// Lap 2: 1 21:00, 2 21:00, 3 21:30
+		testutil.Incoming("10:21:00.000", domain.EventEndedMainLap, 1, ""),
+		testutil.Incoming("10:22:00.000", domain.EventEndedMainLap, 2, ""),
+		testutil.Incoming("10:23:30.000", domain.EventEndedMainLap, 3, ""),
+		// Lap 3: 1 33:00, 2 31:30, 3 30:00
+		testutil.Incoming("10:32:00.000", domain.EventEndedMainLap, 3, ""),
+		testutil.Incoming("10:32:30.000", domain.EventEndedMainLap, 2, ""),
+		testutil.Incoming("10:33:00.000", domain.EventEndedMainLap, 1, ""),
+	)
+	processAll(t, service, events...)
+	return service
+}
+
+func TestGetPositionChart(t *testing.T) {
+	chart := newPositionsCompetition(t).GetPositionChart()
+	require.Len(t, chart, 4)
+	assert.Equal(t, 3, chart.Laps())
+
+	byID := make(map[int]*LapPositions)
+	for _, positions := range chart {
+		byID[positions.Competitor.ID] = positions
+	}
+	assert.Equal(t, []int{3, 3, 1}, byID[3].Positions)
+	assert.Equal(t, 1, byID[3].Rank)
+	assert.Equal(t, []int{2, 1, 2}, byID[2].Positions)
+	assert.Equal(t, []int{1, 1, 3}, byID[1].Positions, "1 and 2 share the lead after lap 2")
+	assert.Equal(t, []int{4}, byID[4].Positions)
+	assert.Equal(t, 0, byID[4].Rank)
+
+	assert.Equal(t, 2, byID[3].Change())
+	assert.Equal(t, -2, byID[1].Change())
+	assert.Equal(t, 0, byID[2].Change())
+
+	// The chart follows the results order
+	assert.Equal(t, 3, chart[0].Competitor.ID)
+	assert.Equal(t, 4, chart[3].Competitor.ID)
+}
+
+func TestPositionChart_Summary(t *testing.T) {
+	chart := newPositionsCompetition(t).GetPositionChart()
+	assert.Equal(t, `Positions by lap
+competitor(3): 3 3 1
+competitor(2): 2 1 2
+competitor(1): 1 1 3
+competitor(4): 4
+
+Biggest gainers
+  competitor(3): 3 -> 1 (+2)
+
+Biggest losers
+  competitor(1): 1 -> 3 (-2)
+`, chart.Summary(3))
+}
+
+func TestPositionChart_Export(t *testing.T) {
+	chart := newPositionsCompetition(t).GetPositionChart()
+
+	var csv bytes.Buffer
+	require.NoError(t, chart.WriteCSV(&csv))
+	assert.Equal(t, `competitor,name,rank,lap 1,lap 2,lap 3
+3,,1,3,3,1
+2,,2,2,1,2
+1,,3,1,1,3
+4,,,4,,
+`, csv.String())
+
+	data, err := json.Marshal(chart[:1])
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"competitorId": 3, "rank": 1, "positions": [3, 3, 1],
+		"times": ["00:11:00.000", "00:21:30.000", "00:30:00.000"]}]`, string(data))
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+var (
+	ErrInvalidCompetitionID = errors.New("invalid competition ID")
+	ErrDuplicateCompetition = errors.New("duplicate competition")
+	ErrUnknownCompetition   = errors.New("unknown competition")
+	ErrMissingCompetitionID = errors.New("event without a competition ID")
+)
+
+var competitionIDRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Registry hosts several competitions run side by side, such as the races of a
+// weekend, and routes events to them by competition ID. It is safe for concurrent use;
+// events of all competitions are processed one at a time.
+type Registry struct {
+	mu           sync.Mutex
+	competitions map[string]*CompetitionService
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{competitions: make(map[string]*CompetitionService)}
+}
+
+// Add hosts a competition under the given ID. IDs are made of letters, digits, '_', '.'
+// and '-' so they can prefix event lines.
+func (r *Registry) Add(id string, competition *CompetitionService) error {
+	if !competitionIDRegex.MatchString(id) {
+		return fmt.Errorf("%w: %q", ErrInvalidCompetitionID, id)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.competitions[id]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateCompetition, id)
+	}
+	r.competitions[id] = competition
+	return nil
+}
+
+// Get returns the competition with the given ID
+func (r *Registry) Get(id string) (*CompetitionService, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	competition, ok := r.competitions[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCompetition, id)
+	}
+	return competition, nil
+}
+
+// IDs returns the IDs of all competitions in alphabetical order
+func (r *Registry) IDs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.competitions))
+	for id := range r.competitions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ProcessEvent applies an event to the competition with the given ID
+func (r *Registry) ProcessEvent(id string, event *domain.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	competition, ok := r.competitions[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCompetition, id)
+	}
+	if err := competition.ProcessEvent(event); err != nil {
+		return fmt.Errorf("%s: %w", id, err)
+	}
+	return nil
+}
+
+// ProcessLine parses a line of a combined event stream and routes the event to its
+// competition. The line is an event prefixed by the competition ID:
+// `id [hh:mm:ss.sss] eventID competitorID extra`.
+func (r *Registry) ProcessLine(line string) error {
+	id, event, err := ParseRoutedEvent(line)
+	if err != nil {
+		return err
+	}
+	return r.ProcessEvent(id, event)
+}
+
+// Do runs f with the competition while no events are processed, so that f sees a
+// consistent state of the race
+func (r *Registry) Do(id string, f func(competition *CompetitionService) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	competition, ok := r.competitions[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownCompetition, id)
+	}
+	return f(competition)
+}
+
+// ExportResults returns the results export of every competition by ID
+func (r *Registry) ExportResults() map[string]*ResultsExport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	exports := make(map[string]*ResultsExport, len(r.competitions))
+	for id, competition := range r.competitions {
+		exports[id] = competition.ExportResults()
+	}
+	return exports
+}
+
+// ParseRoutedEvent splits a line of a combined event stream into the competition ID
+// and the event. A valid event without the ID fails with ErrMissingCompetitionID, so
+// it is not mistaken for a line that is no event at all.
+func ParseRoutedEvent(line string) (string, *domain.Event, error) {
+	line = strings.TrimSpace(line)
+	id, rest, found := strings.Cut(line, " ")
+	if !found || !competitionIDRegex.MatchString(id) {
+		if _, err := domain.ParseEvent(line); err == nil {
+			return "", nil, fmt.Errorf("%w: %q", ErrMissingCompetitionID, line)
+		}
+		return "", nil, fmt.Errorf("%w: %q", domain.ErrInvalidEventFormat, line)
+	}
+	event, err := domain.ParseEvent(rest)
+	if err != nil {
+		return "", nil, err
+	}
+	return id, event, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+	registry := NewRegistry()
+	require.NoError(t, registry.Add("sprint-men", NewCompetitionService(testutil.Config())))
+	require.NoError(t, registry.Add("sprint-women", NewCompetitionService(testutil.Config())))
+	return registry
+}
+
+func TestRegistryAdd(t *testing.T) {
+	registry := newTestRegistry(t)
+	assert.Equal(t, []string{"sprint-men", "sprint-women"}, registry.IDs())
+
+	err := registry.Add("sprint-men", NewCompetitionService(testutil.Config()))
+	assert.ErrorIs(t, err, ErrDuplicateCompetition)
+	for _, id := range []string{"", "two words", "-sprint", "sprint/men"} {
+		err := registry.Add(id, NewCompetitionService(testutil.Config()))
+		assert.ErrorIs(t, err, ErrInvalidCompetitionID, id)
+	}
+
+	_, err = registry.Get("relay")
+	assert.ErrorIs(t, err, ErrUnknownCompetition)
+}
+
+func TestRegistryRoutesEvents(t *testing.T) {
+	registry := newTestRegistry(t)
+	require.NoError(t, registry.ProcessLine("sprint-men [09:00:00.000] 1 1"))
+	require.NoError(t, registry.ProcessLine("sprint-women [09:00:00.000] 1 1"))
+	require.NoError(t, registry.ProcessLine("sprint-women [09:00:01.000] 1 2"))
+	require.NoError(t, registry.ProcessEvent("sprint-men", testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000")))
+
+	men, err := registry.Get("sprint-men")
+	require.NoError(t, err)
+	women, err := registry.Get("sprint-women")
+	require.NoError(t, err)
+	assert.Len(t, men.GetCompetitors(), 1)
+	assert.Len(t, women.GetCompetitors(), 2)
+	assert.Equal(t, domain.StatusRegistered, women.GetCompetitors()[0].Status)
+	assert.Len(t, men.GetLogEntries(), 2)
+
+	exports := registry.ExportResults()
+	assert.Len(t, exports["sprint-women"].Results, 2)
+
+	count := 0
+	require.NoError(t, registry.Do("sprint-men", func(competition *CompetitionService) error {
+		count = len(competition.GetCompetitors())
+		return nil
+	}))
+	assert.Equal(t, 1, count)
+}
+
+func TestRegistryProcessLineErrors(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	err := registry.ProcessLine("relay [09:00:00.000] 1 1")
+	assert.ErrorIs(t, err, ErrUnknownCompetition)
+
+	// A valid event without the prefix is not silently taken for a malformed line
+	err = registry.ProcessLine("[09:00:00.000] 1 1")
+	assert.ErrorIs(t, err, ErrMissingCompetitionID)
+	assert.NotErrorIs(t, err, domain.ErrInvalidEventFormat)
+
+	err = registry.ProcessLine("")
+	assert.ErrorIs(t, err, domain.ErrInvalidEventFormat)
+
+	err = registry.ProcessLine("sprint-men garbage")
+	assert.ErrorIs(t, err, domain.ErrInvalidEventFormat)
+
+	// Errors of the competition name the race
+	err = registry.ProcessLine("sprint-men [09:00:00.000] 4 1 1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sprint-men: ")
+}
+
+func TestParseRoutedEvent(t *testing.T) {
+	id, event, err := ParseRoutedEvent("sprint-men [09:05:59.867] 11 1 Lost in the forest")
+	require.NoError(t, err)
+	assert.Equal(t, "sprint-men", id)
+	assert.Equal(t, int(domain.EventCannotContinue), event.EventID)
+	assert.Equal(t, 1, event.CompetitorID)
+	assert.Equal(t, "Lost in the forest", event.ExtraParams)
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// ReorderBuffer holds incoming events back until they are older than the newest event
+// by the watermark delay, then applies them to the competition in time order. Feeds
+// from the range, the course and the finish arrive slightly out of order; a delay a
+// little longer than the worst skew between them puts the events back in order.
+// Events also leave the buffer once they have waited the delay in wall-clock time,
+// see Expire, so the last events of a race do not wait for a newer one.
+type ReorderBuffer struct {
+	competition *CompetitionService
+	delay       time.Duration
+	pending     []*domain.Event // sorted by time, in arrival order for equal times
+	arrived     []time.Time     // wall-clock arrival of each pending event
+	now         func() time.Time
+	latest      time.Time // time of the newest event added
+	watermark   time.Time // time of the last applied event
+	started     bool      // an event was added
+	applied     bool      // an event was applied
+}
+
+// NewReorderBuffer creates a buffer in front of the competition. A zero delay applies
+// every event right away, still reprocessing the competitor for late ones.
+func NewReorderBuffer(competition *CompetitionService, delay time.Duration) *ReorderBuffer {
+	return &ReorderBuffer{competition: competition, delay: delay, now: time.Now}
+}
+
+// Add buffers an event and applies the events the watermark has passed. An event
+// older than the events already applied arrived beyond the watermark: its competitor
+// is reprocessed with the event sorted in.
+func (b *ReorderBuffer) Add(event *domain.Event) error {
+	if b.applied && event.Time.Before(b.watermark) {
+		return b.competition.ReprocessCompetitor(event)
+	}
+
+	i := sort.Search(len(b.pending), func(i int) bool { return b.pending[i].Time.After(event.Time) })
+	b.pending = append(b.pending, nil)
+	copy(b.pending[i+1:], b.pending[i:])
+	b.pending[i] = event
+	b.arrived = append(b.arrived, time.Time{})
+	copy(b.arrived[i+1:], b.arrived[i:])
+	b.arrived[i] = b.now()
+
+	if !b.started || event.Time.After(b.latest) {
+		b.latest = event.Time
+		b.started = true
+	}
+	return b.release(b.latest.Add(-b.delay))
+}
+
+// Expire applies the events that arrived at least the delay before now, together
+// with the events before them in time. Live inputs call it periodically.
+func (b *ReorderBuffer) Expire(now time.Time) error {
+	var until time.Time
+	expired := false
+	for i, arrived := range b.arrived {
+		if now.Sub(arrived) >= b.delay {
+			until = b.pending[i].Time
+			expired = true
+		}
+	}
+	if !expired {
+		return nil
+	}
+	return b.release(until)
+}
+
+// Flush applies all buffered events, at the end of the stream
+func (b *ReorderBuffer) Flush() error {
+	return b.release(b.latest)
+}
+
+// Pending returns the events waiting for the watermark, in time order
+func (b *ReorderBuffer) Pending() []*domain.Event {
+	return b.pending
+}
+
+// release applies the buffered events up to the given time. All of them are applied
+// even when one fails; the first error is returned.
+func (b *ReorderBuffer) release(until time.Time) error {
+	var first error
+	for len(b.pending) > 0 && !b.pending[0].Time.After(until) {
+		event := b.pending[0]
+		b.pending, b.arrived = b.pending[1:], b.arrived[1:]
+		b.watermark = event.Time
+		b.applied = true
+		if err := b.competition.ProcessEvent(event); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// ReprocessCompetitor applies a late event by rebuilding the competitor from its
+// applied events with the late one sorted in by time. The competitor's log entries
+// are replaced. Handlers check the late event before it is applied but are not called
+// while the competitor is replayed. When the replay fails the competition is left as
+// it was before the late event.
+func (s *CompetitionService) ReprocessCompetitor(late *domain.Event) error {
+	if _, exists := s.competitors[late.CompetitorID]; !exists || s.isDuplicate(late) {
+		return s.ProcessEvent(late)
+	}
+	if err := s.handleBefore(late); err != nil {
+		return err
+	}
+
+	saved := s.saveCompetitor(late.CompetitorID)
+
+	// Take the competitor's events out of the competition
+	id := late.CompetitorID
+	replay := make([]*domain.Event, 0)
+	events := make([]*domain.Event, 0, len(s.events))
+	produced := 0
+	for _, event := range s.events {
+		if event.CompetitorID != id {
+			events = append(events, event)
+			continue
+		}
+		if event.Type == domain.EventTypeOutgoing {
+			produced++
+		} else {
+			replay = append(replay, event)
+			delete(s.seen, event.Key())
+			delete(s.recent, similarKey(event))
+		}
+	}
+	replay = append(replay, late)
+	sort.SliceStable(replay, func(i, j int) bool { return replay[i].Time.Before(replay[j].Time) })
+
+	log := make([]string, 0, len(s.log))
+	meta := make([]logMeta, 0, len(s.logMeta))
+	logged := make(map[string][]int)
+	for i, entry := range s.log {
+		if s.logMeta[i].competitorID != id {
+			log = append(log, entry)
+			meta = append(meta, s.logMeta[i])
+		} else {
+			logged[entry] = append(logged[entry], s.logMeta[i].seq)
+		}
+	}
+	delete(s.competitors, id)
+	s.events, s.log, s.logMeta = nil, nil, nil
+
+	// Replay the competitor alone, then merge it back in by time
+	s.replaying = true
+	var err error
+	for _, event := range replay {
+		if err = s.ProcessEvent(event); err != nil {
+			err = fmt.Errorf("reprocessing competitor %d: %w", id, err)
+			break
+		}
+	}
+	s.replaying = false
+	if err != nil {
+		s.restoreCompetitor(saved)
+		return err
+	}
+
+	// Only the late event and the outgoing events the rebuild adds are new to the handlers
+	outgoing := make([]*domain.Event, 0)
+	for _, event := range s.events {
+		if event.Type == domain.EventTypeOutgoing {
+			if produced > 0 {
+				produced--
+				continue
+			}
+			outgoing = append(outgoing, event)
+		}
+	}
+
+	// Entries logged again unchanged keep their position, see GetNewLogEntries
+	for i, entry := range s.log {
+		if seqs := logged[entry]; len(seqs) > 0 {
+			s.logMeta[i].seq, logged[entry] = seqs[0], seqs[1:]
+		}
+	}
+
+	s.events = mergeEvents(events, s.events)
+	s.log, s.logMeta = mergeLog(log, meta, s.log, s.logMeta)
+	s.handleAfter(late, outgoing)
+	return nil
+}
+
+// savedCompetitor is the state a failed reprocessing restores
+type savedCompetitor struct {
+	id         int
+	competitor *domain.Competitor
+	events     []*domain.Event
+	log        []string
+	logMeta    []logMeta
+	seen       map[string]bool
+	recent     map[string]*domain.Event
+	duplicates []*domain.Event
+}
+
+// saveCompetitor keeps the competitor and the competition's event state. The replay
+// builds a new competitor and new slices, so only the maps it changes in place are copied.
+func (s *CompetitionService) saveCompetitor(id int) *savedCompetitor {
+	saved := &savedCompetitor{
+		id:         id,
+		competitor: s.competitors[id],
+		events:     s.events,
+		log:        s.log,
+		logMeta:    s.logMeta,
+		seen:       make(map[string]bool, len(s.seen)),
+		recent:     make(map[string]*domain.Event, len(s.recent)),
+		duplicates: s.duplicates,
+	}
+	for key, value := range s.seen {
+		saved.seen[key] = value
+	}
+	for key, value := range s.recent {
+		saved.recent[key] = value
+	}
+	return saved
+}
+
+func (s *CompetitionService) restoreCompetitor(saved *savedCompetitor) {
+	s.competitors[saved.id] = saved.competitor
+	s.events, s.log, s.logMeta = saved.events, saved.log, saved.logMeta
+	s.seen, s.recent = saved.seen, saved.recent
+	s.duplicates = saved.duplicates
+}
+
+// mergeEvents inserts the replayed events, in time order, into the remaining ones
+// without changing the order of the remaining events
+func mergeEvents(events, replayed []*domain.Event) []*domain.Event {
+	merged := make([]*domain.Event, 0, len(events)+len(replayed))
+	j := 0
+	for _, event := range events {
+		for j < len(replayed) && replayed[j].Time.Before(event.Time) {
+			merged = append(merged, replayed[j])
+			j++
+		}
+		merged = append(merged, event)
+	}
+	return append(merged, replayed[j:]...)
+}
+
+// mergeLog inserts the replayed log entries like mergeEvents
+func mergeLog(log []string, meta []logMeta, replayed []string, replayedMeta []logMeta) ([]string, []logMeta) {
+	mergedLog := make([]string, 0, len(log)+len(replayed))
+	mergedMeta := make([]logMeta, 0, len(meta)+len(replayedMeta))
+	j := 0
+	for i, entry := range log {
+		for j < len(replayed) && replayedMeta[j].time.Before(meta[i].time) {
+			mergedLog = append(mergedLog, replayed[j])
+			mergedMeta = append(mergedMeta, replayedMeta[j])
+			j++
+		}
+		mergedLog = append(mergedLog, entry)
+		mergedMeta = append(mergedMeta, meta[i])
+	}
+	return append(mergedLog, replayed[j:]...), append(mergedMeta, replayedMeta[j:]...)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addAll(t *testing.T, buffer *ReorderBuffer, events ...*domain.Event) {
+	t.Helper()
+	for _, event := range events {
+		require.NoError(t, buffer.Add(event))
+	}
+}
+
+func TestReorderBuffer_SortsWithinDelay(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	buffer := NewReorderBuffer(service, 5*time.Second)
+	addAll(t, buffer,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		// The target system reports a hit before the course reports the range entry
+		testutil.Incoming("10:05:01.000", domain.EventTargetHit, 1, "1"),
+		testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1"),
+		testutil.Incoming("10:05:20.000", domain.EventLeftFiringRange, 1, ""),
+	)
+
+	require.Len(t, buffer.Pending(), 1, "the range exit waits for the watermark")
+	competitor := service.competitors[1]
+	require.Len(t, competitor.Ranges, 1)
+	assert.Equal(t, 1, competitor.Ranges[0].Hits)
+
+	require.NoError(t, buffer.Flush())
+	assert.Empty(t, buffer.Pending())
+	assert.Equal(t, 20*time.Second, competitor.Ranges[0].Time)
+	assert.Contains(t, service.GetLogEntries()[4], "[10:05:01.000]")
+}
+
+func TestReorderBuffer_KeepsArrivalOrderForEqualTimes(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	buffer := NewReorderBuffer(service, time.Minute)
+	addAll(t, buffer,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 2, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+	)
+	assert.Empty(t, service.GetLogEntries())
+	require.NoError(t, buffer.Flush())
+
+	entries := service.GetLogEntries()
+	require.Len(t, entries, 2)
+	assert.Contains(t, entries[0], "competitor(2)")
+}
+
+func TestReorderBuffer_Expire(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	buffer := NewReorderBuffer(service, 5*time.Second)
+	clock := time.Date(2024, 1, 13, 10, 0, 0, 0, time.UTC)
+	buffer.now = func() time.Time { return clock }
+
+	addAll(t, buffer,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 2, ""),
+	)
+	clock = clock.Add(3 * time.Second)
+	// Arrives later but belongs before the registration of 2
+	addAll(t, buffer, testutil.Incoming("08:59:59.000", domain.EventRegistered, 3, ""))
+	require.Len(t, buffer.Pending(), 3, "no newer event moves the watermark")
+
+	require.NoError(t, buffer.Expire(clock.Add(time.Second)))
+	assert.Len(t, buffer.Pending(), 3, "nothing has waited the delay yet")
+
+	require.NoError(t, buffer.Expire(clock.Add(2*time.Second)))
+	assert.Empty(t, buffer.Pending(), "the expired registrations take the earlier one along")
+	entries := service.GetLogEntries()
+	require.Len(t, entries, 3)
+	assert.Contains(t, entries[0], "competitor(3)")
+
+	// Later events are still ordered by the watermark
+	addAll(t, buffer, testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"))
+	assert.Len(t, buffer.Pending(), 1)
+}
+
+func TestReorderBuffer_ReprocessesLateEvents(t *testing.T) {
+	config := testutil.Config()
+	config.Laps = 2
+	config.LapLen = 3000
+
+	service := NewCompetitionService(config)
+	var notified []int
+	service.AddHandler(AfterFunc(func(s *CompetitionService, event *domain.Event) {
+		notified = append(notified, event.EventID)
+	}))
+
+	buffer := NewReorderBuffer(service, 0)
+	addAll(t, buffer,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 2, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1"),
+		testutil.Incoming("10:05:30.000", domain.EventLeftFiringRange, 1, ""),
+		testutil.Incoming("10:07:00.000", domain.EventEnteredPenaltyLaps, 1, ""),
+		testutil.Incoming("10:10:00.000", domain.EventEndedMainLap, 1, ""),
+		testutil.Incoming("10:11:00.000", domain.EventCannotContinue, 2, "Cold"),
+	)
+	notifications := len(notified)
+
+	// The penalty exit arrives after the lap end was applied
+	require.NoError(t, buffer.Add(testutil.Incoming("10:09:00.000", domain.EventLeftPenaltyLaps, 1, "")))
+
+	competitor := service.competitors[1]
+	require.Len(t, competitor.Penalties, 1)
+	assert.Equal(t, 2*time.Minute, competitor.Penalties[0].Time)
+	require.Len(t, competitor.Laps, 1)
+	assert.Equal(t, 10*time.Minute, competitor.Laps[0].Time)
+	assert.Equal(t, domain.StatusRacing, competitor.Status)
+	assert.Equal(t, domain.StatusNotFinished, service.competitors[2].Status)
+	assert.Equal(t, []int{int(domain.EventLeftPenaltyLaps)}, notified[notifications:],
+		"only the late event is handed to the handlers, not the rebuilt ones")
+
+	entries := service.GetLogEntries()
+	require.Len(t, entries, 10)
+	assert.Contains(t, entries[7], "[10:09:00.000] The competitor(1) left the penalty laps")
+	assert.Contains(t, entries[8], "[10:10:00.000] The competitor(1) ended the main lap")
+	assert.Contains(t, entries[9], "[10:11:00.000] The competitor(2) can't continue")
+
+	// The rebuilt competitor still recognizes retransmissions
+	require.NoError(t, buffer.Add(testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1")))
+	assert.Len(t, service.GetDuplicates(), 1)
+	assert.Len(t, competitor.Ranges, 1)
+}
+
+func TestGetNewLogEntries_LateEvent(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	buffer := NewReorderBuffer(service, 0)
+	addAll(t, buffer,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 2, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1"),
+		testutil.Incoming("10:05:20.000", domain.EventLeftFiringRange, 1, ""),
+	)
+	printed, position := service.GetNewLogEntries(0)
+	require.Len(t, printed, 6)
+
+	// The late hit is logged in between, only it is new
+	addAll(t, buffer, testutil.Incoming("10:05:10.000", domain.EventTargetHit, 1, "1"))
+	entries, position := service.GetNewLogEntries(position)
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0], "[10:05:10.000]")
+	assert.Equal(t, entries[0], service.GetLogEntries()[5])
+
+	entries, _ = service.GetNewLogEntries(position)
+	assert.Empty(t, entries)
+}
+
+func TestReprocessCompetitor_UnregisteredCompetitor(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	err := service.ReprocessCompetitor(testutil.Incoming("10:00:00.000", domain.EventStarted, 9, ""))
+	assert.Error(t, err)
+
+	require.NoError(t, service.ReprocessCompetitor(testutil.Incoming("09:00:00.000", domain.EventRegistered, 9, "")))
+	assert.Len(t, service.GetCompetitors(), 1)
+}
+
+func TestReprocessCompetitor_FailureKeepsCompetitor(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	var notified []int
+	service.AddHandler(AfterFunc(func(s *CompetitionService, event *domain.Event) {
+		notified = append(notified, event.EventID)
+	}))
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1"),
+	)
+	competitor := service.competitors[1]
+	events := len(service.GetEvents())
+	log := append([]string(nil), service.GetLogEntries()...)
+	notifications := len(notified)
+
+	// A range entry before the registration cannot be replayed
+	err := service.ReprocessCompetitor(testutil.Incoming("08:59:00.000", domain.EventOnFiringRange, 1, "1"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reprocessing competitor 1")
+
+	assert.Same(t, competitor, service.competitors[1])
+	assert.Equal(t, domain.StatusOnFiringRange, competitor.Status)
+	assert.Len(t, service.GetEvents(), events)
+	assert.Equal(t, log, service.GetLogEntries())
+	assert.Len(t, notified, notifications, "a failed late event is not handed to the handlers")
+
+	// The competitor's events are still known, and the race goes on
+	require.NoError(t, service.ProcessEvent(testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1")))
+	assert.Len(t, service.GetDuplicates(), 1)
+	require.NoError(t, service.ProcessEvent(testutil.Incoming("10:05:30.000", domain.EventLeftFiringRange, 1, "")))
+	assert.Len(t, competitor.Ranges, 1)
+}
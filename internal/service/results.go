@@ -0,0 +1,246 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// Result represents a single line of the results table
+type Result struct {
+	Rank       int // 0 for competitors without a valid finish
+	Competitor *domain.Competitor
+	Status     domain.CompetitorStatus
+	Time       time.Duration
+	Behind     time.Duration
+
+	// Time breakdown of finishers with the rank of each component in the field.
+	// Ski time is the time on the course, excluding the range and penalty loops.
+	SkiTime     time.Duration
+	RangeTime   time.Duration
+	PenaltyTime time.Duration
+	SkiRank     int
+	RangeRank   int
+	PenaltyRank int
+}
+
+// CategoryResults holds the ranked results of one category
+type CategoryResults struct {
+	Category string
+	Results  []*Result
+}
+
+// GetResults returns all competitors ranked by their race time.
+// Finishers come first, followed by non-finishers, non-starters and disqualified competitors.
+func (s *CompetitionService) GetResults() []*Result {
+	competitors := make([]*domain.Competitor, 0, len(s.competitors))
+	for _, competitor := range s.competitors {
+		competitors = append(competitors, competitor)
+	}
+	return rankCompetitors(competitors)
+}
+
+// GetCategoryResults returns the results ranked separately within each category.
+// Categories are ordered by name; competitors without a category are listed last.
+func (s *CompetitionService) GetCategoryResults() []CategoryResults {
+	groups := make(map[string][]*domain.Competitor)
+	for _, competitor := range s.competitors {
+		category := s.categoryOf(competitor)
+		groups[category] = append(groups[category], competitor)
+	}
+
+	categories := make([]string, 0, len(groups))
+	for category := range groups {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i] == "" || categories[j] == "" {
+			return categories[j] == ""
+		}
+		return categories[i] < categories[j]
+	})
+
+	results := make([]CategoryResults, 0, len(categories))
+	for _, category := range categories {
+		results = append(results, CategoryResults{
+			Category: category,
+			Results:  rankCompetitors(groups[category]),
+		})
+	}
+	return results
+}
+
+// GetCategoryReport renders the per-category results as text
+func (s *CompetitionService) GetCategoryReport() string {
+	report := ""
+	for _, group := range s.GetCategoryResults() {
+		category := group.Category
+		if category == "" {
+			category = s.printer.Sprintf("category.none")
+		}
+		report += fmt.Sprintf("%s\n", category)
+		for _, result := range group.Results {
+			report += formatResultLine(result) + "\n"
+		}
+	}
+	return report
+}
+
+// GetBreakdownReport renders the ski, range and penalty times of the finishers
+// with the rank of each component
+func (s *CompetitionService) GetBreakdownReport() string {
+	report := ""
+	for _, result := range s.GetResults() {
+		if result.Rank == 0 {
+			continue
+		}
+		line := fmt.Sprintf("%d. %d", result.Rank, result.Competitor.ID)
+		if name := result.Competitor.Name(); name != "" {
+			line += " " + name
+		}
+		report += fmt.Sprintf("%s ski %s (%d), range %s (%d), penalty %s (%d)\n", line,
+			FormatDuration(result.SkiTime), result.SkiRank,
+			FormatDuration(result.RangeTime), result.RangeRank,
+			FormatDuration(result.PenaltyTime), result.PenaltyRank)
+	}
+	return report
+}
+
+func formatResultLine(result *Result) string {
+	competitor := result.Competitor
+	rank := "-"
+	if result.Rank > 0 {
+		rank = fmt.Sprintf("%d", result.Rank)
+	}
+
+	line := fmt.Sprintf("%s. %d", rank, competitor.ID)
+	if athlete := competitor.Athlete; athlete != nil {
+		line += " " + athlete.Name
+		if affiliation := athlete.Affiliation(); affiliation != "" {
+			line += ", " + affiliation
+		}
+	}
+
+	if result.Rank == 0 {
+		return line + " " + getStatusString(result.Status)
+	}
+	return line + fmt.Sprintf(" %s +%s", FormatDuration(result.Time), FormatDuration(result.Behind))
+}
+
+// resultStatus maps the live status of a competitor onto one of the final result statuses
+func resultStatus(competitor *domain.Competitor) domain.CompetitorStatus {
+	switch competitor.Status {
+	case domain.StatusFinished, domain.StatusDisqualified, domain.StatusNotFinished, domain.StatusNotStarted:
+		return competitor.Status
+	case domain.StatusRegistered, domain.StatusOnStartLine:
+		return domain.StatusNotStarted
+	default:
+		return domain.StatusNotFinished
+	}
+}
+
+// raceStart returns the planned start of a competitor, falling back to the
+// actual start when no start time was drawn
+func raceStart(competitor *domain.Competitor) time.Time {
+	if competitor.PlannedStart.IsZero() {
+		return competitor.StartTime
+	}
+	return competitor.PlannedStart
+}
+
+// raceTime returns the time from the start to the finish
+func raceTime(competitor *domain.Competitor) time.Duration {
+	return competitor.FinishTime.Sub(raceStart(competitor))
+}
+
+func statusOrder(status domain.CompetitorStatus) int {
+	switch status {
+	case domain.StatusFinished:
+		return 0
+	case domain.StatusNotFinished:
+		return 1
+	case domain.StatusNotStarted:
+		return 2
+	default:
+		return 3
+	}
+}
+
+func rankCompetitors(competitors []*domain.Competitor) []*Result {
+	results := make([]*Result, 0, len(competitors))
+	for _, competitor := range competitors {
+		result := &Result{
+			Competitor: competitor,
+			Status:     resultStatus(competitor),
+		}
+		if result.Status == domain.StatusFinished {
+			result.Time = raceTime(competitor)
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if statusOrder(a.Status) != statusOrder(b.Status) {
+			return statusOrder(a.Status) < statusOrder(b.Status)
+		}
+		if a.Time != b.Time {
+			return a.Time < b.Time
+		}
+		return a.Competitor.ID < b.Competitor.ID
+	})
+
+	for i, result := range results {
+		if result.Status != domain.StatusFinished {
+			break
+		}
+		result.Behind = result.Time - results[0].Time
+		result.Rank = i + 1
+		if i > 0 && result.Time == results[i-1].Time {
+			result.Rank = results[i-1].Rank
+		}
+	}
+	rankComponents(results)
+	return results
+}
+
+// rankComponents fills in the time breakdown of the finishers and ranks each component
+func rankComponents(results []*Result) {
+	finishers := make([]*Result, 0, len(results))
+	for _, result := range results {
+		if result.Status != domain.StatusFinished {
+			continue
+		}
+		competitor := result.Competitor
+		for _, visit := range competitor.Ranges {
+			result.RangeTime += visit.Time
+		}
+		for _, penalty := range competitor.Penalties {
+			result.PenaltyTime += penalty.Time
+		}
+		result.SkiTime = competitor.FinishTime.Sub(competitor.StartTime) - result.RangeTime - result.PenaltyTime
+		finishers = append(finishers, result)
+	}
+
+	rankBy(finishers, func(r *Result) time.Duration { return r.SkiTime }, func(r *Result, rank int) { r.SkiRank = rank })
+	rankBy(finishers, func(r *Result) time.Duration { return r.RangeTime }, func(r *Result, rank int) { r.RangeRank = rank })
+	rankBy(finishers, func(r *Result) time.Duration { return r.PenaltyTime }, func(r *Result, rank int) { r.PenaltyRank = rank })
+}
+
+// rankBy assigns ranks by ascending value; equal values share a rank
+func rankBy(results []*Result, value func(*Result) time.Duration, set func(*Result, int)) {
+	ordered := make([]*Result, len(results))
+	copy(ordered, results)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return value(ordered[i]) < value(ordered[j])
+	})
+	rank := 0
+	for i, result := range ordered {
+		if i == 0 || value(result) != value(ordered[i-1]) {
+			rank = i + 1
+		}
+		set(result, rank)
+	}
+}
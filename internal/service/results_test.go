@@ -0,0 +1,230 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func processAll(t *testing.T, service *CompetitionService, events ...*domain.Event) {
+	t.Helper()
+	for _, event := range events {
+		require.NoError(t, service.ProcessEvent(event))
+	}
+}
+
+// raceEvents returns the events of a single-lap race for one competitor
+func raceEvents(competitorID int, plannedStart, finish string) []*domain.Event {
+	return []*domain.Event{
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, competitorID, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, competitorID, plannedStart),
+		testutil.Incoming(plannedStart, domain.EventStarted, competitorID, ""),
+		testutil.Incoming(finish, domain.EventEndedMainLap, competitorID, ""),
+	}
+}
+
+func TestGetResults_Ranking(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	processAll(t, service, raceEvents(1, "10:00:00.000", "10:12:00.000")...)
+	processAll(t, service, raceEvents(2, "10:01:30.000", "10:12:30.000")...)
+	processAll(t, service, raceEvents(3, "10:03:00.000", "10:14:00.000")...)
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 4, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 5, ""),
+		testutil.Incoming("10:04:30.000", domain.EventStarted, 5, ""),
+		testutil.Incoming("10:06:00.000", domain.EventCannotContinue, 5, "Lost in the forest"),
+	)
+
+	results := service.GetResults()
+	require.Len(t, results, 5)
+
+	assert.Equal(t, 2, results[0].Competitor.ID)
+	assert.Equal(t, 1, results[0].Rank)
+	assert.Equal(t, 11*time.Minute, results[0].Time)
+	assert.Equal(t, time.Duration(0), results[0].Behind)
+
+	// Competitors 3 and 2 share the same race time
+	assert.Equal(t, 3, results[1].Competitor.ID)
+	assert.Equal(t, 1, results[1].Rank)
+
+	assert.Equal(t, 1, results[2].Competitor.ID)
+	assert.Equal(t, 3, results[2].Rank)
+	assert.Equal(t, time.Minute, results[2].Behind)
+
+	assert.Equal(t, 5, results[3].Competitor.ID)
+	assert.Equal(t, domain.StatusNotFinished, results[3].Status)
+	assert.Equal(t, 0, results[3].Rank)
+
+	assert.Equal(t, 4, results[4].Competitor.ID)
+	assert.Equal(t, domain.StatusNotStarted, results[4].Status)
+	assert.Equal(t, 0, results[4].Rank)
+}
+
+func TestGetCategoryResults(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	athletes, err := domain.NewAthleteRegistry([]*domain.Athlete{
+		{CompetitorID: 1, Name: "Ingrid Solberg", Gender: "W"},
+		{CompetitorID: 2, Name: "Jonas Keller", Gender: "M", Category: "Men U17"},
+		{CompetitorID: 3, Name: "Pavel Orlov", Gender: "M", Category: "Men U17"},
+	})
+	require.NoError(t, err)
+	service.SetAthletes(athletes)
+
+	processAll(t, service, raceEvents(1, "10:00:00.000", "10:12:00.000")...)
+	processAll(t, service, raceEvents(2, "10:01:30.000", "10:14:30.000")...)
+	processAll(t, service, raceEvents(3, "10:03:00.000", "10:14:00.000")...)
+	processAll(t, service, raceEvents(4, "10:04:30.000", "10:15:00.000")...)
+
+	categories := service.GetCategoryResults()
+	require.Len(t, categories, 3)
+
+	assert.Equal(t, "Men U17", categories[0].Category)
+	require.Len(t, categories[0].Results, 2)
+	assert.Equal(t, 3, categories[0].Results[0].Competitor.ID)
+	assert.Equal(t, 1, categories[0].Results[0].Rank)
+	assert.Equal(t, 2, categories[0].Results[1].Competitor.ID)
+	assert.Equal(t, 2, categories[0].Results[1].Rank)
+	assert.Equal(t, 2*time.Minute, categories[0].Results[1].Behind)
+
+	assert.Equal(t, "Women", categories[1].Category)
+	assert.Equal(t, 1, categories[1].Results[0].Rank)
+
+	assert.Equal(t, "", categories[2].Category)
+	assert.Equal(t, 4, categories[2].Results[0].Competitor.ID)
+
+	report := service.GetCategoryReport()
+	assert.Contains(t, report, "Men U17\n1. 3 Pavel Orlov 00:11:00.000 +00:00:00.000\n2. 2 Jonas Keller 00:13:00.000 +00:02:00.000\n")
+	assert.Contains(t, report, "Unassigned\n1. 4 00:10:30.000 +00:00:00.000\n")
+
+	require.NoError(t, service.SetLanguage("de"))
+	assert.Contains(t, service.GetCategoryReport(), "Ohne Klasse\n1. 4 00:10:30.000 +00:00:00.000\n")
+}
+
+func TestSetAthletes_EventLog(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	athletes, err := domain.NewAthleteRegistry([]*domain.Athlete{{CompetitorID: 1, Name: "Ingrid Solberg"}})
+	require.NoError(t, err)
+	service.SetAthletes(athletes)
+
+	processAll(t, service, raceEvents(1, "10:00:00.000", "10:12:00.000")...)
+	processAll(t, service, testutil.Incoming("09:00:00.000", domain.EventRegistered, 2, ""))
+
+	assert.Equal(t, "Ingrid Solberg", service.competitors[1].Name())
+	assert.Contains(t, service.log[0], "The competitor(1, Ingrid Solberg) registered")
+	assert.Contains(t, service.log[4], "The competitor(1, Ingrid Solberg) has finished")
+	assert.Contains(t, service.log[5], "The competitor(2) registered")
+	assert.Contains(t, service.GetFinalReport(), "[Finished] 1 Ingrid Solberg [")
+}
+
+func TestProcessEvent_CategoryRules(t *testing.T) {
+	config := testutil.Config()
+	config.Categories = []domain.CategoryConfig{
+		{Name: "Men U17", Laps: 2, LapLen: 2000, Competitors: []int{2}},
+	}
+	service := NewCompetitionService(config)
+
+	processAll(t, service, raceEvents(1, "10:00:00.000", "10:10:00.000")...)
+	processAll(t, service, raceEvents(2, "10:01:30.000", "10:05:30.000")...)
+	assert.Equal(t, domain.StatusFinished, service.competitors[1].Status)
+	assert.Equal(t, domain.StatusRacing, service.competitors[2].Status)
+	assert.InDelta(t, 2000.0/240, service.competitors[2].Laps[0].Speed, 1e-9)
+
+	processAll(t, service, testutil.Incoming("10:09:30.000", domain.EventEndedMainLap, 2, ""))
+	assert.Equal(t, domain.StatusFinished, service.competitors[2].Status)
+
+	categories := service.GetCategoryResults()
+	require.Len(t, categories, 2)
+	assert.Equal(t, "Men U17", categories[0].Category)
+	assert.Equal(t, 2, categories[0].Results[0].Competitor.ID)
+	assert.Equal(t, 1, categories[0].Results[0].Rank)
+	assert.Equal(t, 1, categories[1].Results[0].Rank)
+}
+
+func TestProcessEvent_CategoryRulesForGenderedAthlete(t *testing.T) {
+	config := testutil.Config()
+	config.Categories = []domain.CategoryConfig{
+		{Name: "Juniors", Laps: 2, LapLen: 2000, Competitors: []int{2}},
+	}
+	service := NewCompetitionService(config)
+	athletes, err := domain.NewAthleteRegistry([]*domain.Athlete{
+		{CompetitorID: 1, Name: "Erik Haugen", Gender: "M"},
+		{CompetitorID: 2, Name: "Jonas Keller", Gender: "M"},
+	})
+	require.NoError(t, err)
+	service.SetAthletes(athletes)
+
+	processAll(t, service, raceEvents(1, "10:00:00.000", "10:10:00.000")...)
+	processAll(t, service, raceEvents(2, "10:01:30.000", "10:05:30.000")...)
+	assert.Equal(t, domain.StatusRacing, service.competitors[2].Status, "Juniors ski two laps")
+	assert.InDelta(t, 2000.0/240, service.competitors[2].Laps[0].Speed, 1e-9)
+	processAll(t, service, testutil.Incoming("10:09:30.000", domain.EventEndedMainLap, 2, ""))
+
+	categories := service.GetCategoryResults()
+	require.Len(t, categories, 2)
+	assert.Equal(t, "Juniors", categories[0].Category)
+	assert.Equal(t, 2, categories[0].Results[0].Competitor.ID)
+	assert.Equal(t, "Men", categories[1].Category)
+	assert.Equal(t, 1, categories[1].Results[0].Competitor.ID)
+}
+
+func TestGetResults_Breakdown(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	shooting := func(id int, enter, leave, penaltyIn, penaltyOut string) []*domain.Event {
+		events := []*domain.Event{
+			testutil.Incoming(enter, domain.EventOnFiringRange, id, "1"),
+			testutil.Incoming(leave, domain.EventLeftFiringRange, id, ""),
+		}
+		if penaltyIn != "" {
+			events = append(events,
+				testutil.Incoming(penaltyIn, domain.EventEnteredPenaltyLaps, id, ""),
+				testutil.Incoming(penaltyOut, domain.EventLeftPenaltyLaps, id, ""))
+		}
+		return events
+	}
+
+	events := make([]*domain.Event, 0)
+	for _, id := range []int{1, 2, 3} {
+		events = append(events,
+			testutil.Incoming("09:00:00.000", domain.EventRegistered, id, ""),
+			testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, id, "10:00:00.000"),
+			testutil.Incoming("10:00:00.000", domain.EventStarted, id, ""))
+	}
+	// 1 skis fastest but shoots slowly, 2 has the fastest range, 3 the most penalty loops
+	events = append(events, shooting(1, "10:05:00.000", "10:06:00.000", "10:06:05.000", "10:07:05.000")...)
+	events = append(events, shooting(2, "10:06:00.000", "10:06:30.000", "10:06:35.000", "10:07:35.000")...)
+	events = append(events, shooting(3, "10:07:00.000", "10:07:40.000", "10:07:45.000", "10:10:45.000")...)
+	events = append(events,
+		testutil.Incoming("10:15:00.000", domain.EventEndedMainLap, 1, ""),
+		testutil.Incoming("10:16:00.000", domain.EventEndedMainLap, 2, ""),
+		testutil.Incoming("10:17:00.000", domain.EventEndedMainLap, 3, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 4, ""))
+	processAll(t, service, events...)
+
+	results := service.GetResults()
+	require.Len(t, results, 4)
+
+	first := results[0]
+	assert.Equal(t, 13*time.Minute, first.SkiTime)
+	assert.Equal(t, time.Minute, first.RangeTime)
+	assert.Equal(t, time.Minute, first.PenaltyTime)
+	assert.Equal(t, []int{1, 3, 1}, []int{first.SkiRank, first.RangeRank, first.PenaltyRank})
+
+	second := results[1]
+	assert.Equal(t, 14*time.Minute+30*time.Second, second.SkiTime)
+	assert.Equal(t, []int{3, 1, 1}, []int{second.SkiRank, second.RangeRank, second.PenaltyRank})
+
+	third := results[2]
+	assert.Equal(t, 13*time.Minute+20*time.Second, third.SkiTime)
+	assert.Equal(t, []int{2, 2, 3}, []int{third.SkiRank, third.RangeRank, third.PenaltyRank})
+
+	assert.Zero(t, results[3].SkiRank, "non-starters are not ranked")
+
+	assert.Equal(t, `1. 1 ski 00:13:00.000 (1), range 00:01:00.000 (3), penalty 00:01:00.000 (1)
+2. 2 ski 00:14:30.000 (3), range 00:00:30.000 (1), penalty 00:01:00.000 (1)
+3. 3 ski 00:13:20.000 (2), range 00:00:40.000 (2), penalty 00:03:00.000 (3)
+`, service.GetBreakdownReport())
+}
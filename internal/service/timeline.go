@@ -0,0 +1,204 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// TimelineKind identifies the type of a timeline entry
+type TimelineKind string
+
+const (
+	TimelineRegistered TimelineKind = "registered"
+	TimelineDraw       TimelineKind = "draw"
+	TimelineStartLine  TimelineKind = "start-line"
+	TimelineStart      TimelineKind = "start"
+	TimelineRange      TimelineKind = "range"
+	TimelinePenalty    TimelineKind = "penalty"
+	TimelineLap        TimelineKind = "lap"
+	TimelineFinish     TimelineKind = "finish"
+	TimelineNotFinish  TimelineKind = "dnf"
+)
+
+// TimelineEntry is a single step of a competitor's race
+type TimelineEntry struct {
+	Time    time.Time
+	Kind    TimelineKind
+	Text    string
+	Split   time.Duration // duration of the lap, range visit or penalty loops
+	Elapsed time.Duration // since the race start, 0 before it
+	Started bool          // whether Elapsed is meaningful
+}
+
+// MarshalJSON renders the times in the hh:mm:ss.sss format of the events file
+func (e TimelineEntry) MarshalJSON() ([]byte, error) {
+	entry := struct {
+		Time    string       `json:"time"`
+		Kind    TimelineKind `json:"kind"`
+		Text    string       `json:"text"`
+		Split   string       `json:"split,omitempty"`
+		Elapsed string       `json:"elapsed,omitempty"`
+	}{
+		Time: e.Time.Format("15:04:05.000"),
+		Kind: e.Kind,
+		Text: e.Text,
+	}
+	if e.Split > 0 {
+		entry.Split = FormatDuration(e.Split)
+	}
+	if e.Started {
+		entry.Elapsed = FormatDuration(e.Elapsed)
+	}
+	return json.Marshal(entry)
+}
+
+// Timeline is the chronological history of one competitor
+type Timeline struct {
+	Competitor *domain.Competitor
+	Entries    []TimelineEntry
+	Warnings   []string
+}
+
+// MarshalJSON renders the competitor reference together with the entries
+func (t *Timeline) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		CompetitorID int             `json:"competitorId"`
+		Name         string          `json:"name,omitempty"`
+		Entries      []TimelineEntry `json:"entries"`
+		Warnings     []string        `json:"warnings,omitempty"`
+	}{
+		CompetitorID: t.Competitor.ID,
+		Name:         t.Competitor.Name(),
+		Entries:      t.Entries,
+		Warnings:     t.Warnings,
+	})
+}
+
+// GetTimeline returns the chronological timeline of a competitor: start, range visits,
+// penalty loops, laps with split and cumulative times, and the finish or DNF reason
+func (s *CompetitionService) GetTimeline(competitorID int) (*Timeline, error) {
+	competitor, exists := s.competitors[competitorID]
+	if !exists {
+		return nil, fmt.Errorf("competitor %d not registered", competitorID)
+	}
+
+	timeline := &Timeline{
+		Competitor: competitor,
+		Entries:    make([]TimelineEntry, 0),
+		Warnings:   competitor.Warnings,
+	}
+	start := raceStart(competitor)
+	add := func(event *domain.Event, kind TimelineKind, split time.Duration, text string, args ...interface{}) {
+		entry := TimelineEntry{
+			Time:  event.Time,
+			Kind:  kind,
+			Text:  fmt.Sprintf(text, args...),
+			Split: split,
+		}
+		if !competitor.StartTime.IsZero() && !event.Time.Before(start) {
+			entry.Elapsed = event.Time.Sub(start)
+			entry.Started = true
+		}
+		timeline.Entries = append(timeline.Entries, entry)
+	}
+
+	ranges, penalties, laps := 0, 0, 0
+	for _, event := range s.events {
+		if event.CompetitorID != competitorID || event.Type != domain.EventTypeIncoming {
+			continue
+		}
+		switch domain.IncomingEventID(event.EventID) {
+		case domain.EventRegistered:
+			add(event, TimelineRegistered, 0, "Registered")
+		case domain.EventStartTimeSet:
+			add(event, TimelineDraw, 0, "Start time drawn: %s", event.ExtraParams)
+		case domain.EventOnStartLine:
+			add(event, TimelineStartLine, 0, "On the start line")
+		case domain.EventStarted:
+			if late := event.Time.Sub(competitor.PlannedStart); !competitor.PlannedStart.IsZero() && late > 0 {
+				add(event, TimelineStart, 0, "Started %s after the planned start %s",
+					FormatDuration(late), competitor.PlannedStart.Format("15:04:05.000"))
+				continue
+			}
+			add(event, TimelineStart, 0, "Started")
+		case domain.EventOnFiringRange:
+			if ranges >= len(competitor.Ranges) {
+				continue
+			}
+			visit := competitor.Ranges[ranges]
+			ranges++
+			if visit.Time == 0 {
+				add(event, TimelineRange, 0, "Firing range %d: %d hits so far", visit.Line, visit.Hits)
+				continue
+			}
+			add(event, TimelineRange, visit.Time, "Firing range %d: %d/%d hits in %s",
+				visit.Line, visit.Hits, domain.TargetsPerRange, FormatDuration(visit.Time))
+		case domain.EventEnteredPenaltyLaps:
+			misses := 0
+			if ranges > 0 {
+				misses = competitor.Ranges[ranges-1].Misses()
+			}
+			if penalties >= len(competitor.Penalties) {
+				add(event, TimelinePenalty, 0, "Penalty loops: %d in progress", misses)
+				continue
+			}
+			penalty := competitor.Penalties[penalties]
+			penalties++
+			add(event, TimelinePenalty, penalty.Time, "Penalty loops: %d x %d m in %s",
+				misses, s.rulesFor(competitor).PenaltyLen, FormatDuration(penalty.Time))
+		case domain.EventEndedMainLap:
+			if laps >= len(competitor.Laps) {
+				continue
+			}
+			lap := competitor.Laps[laps]
+			laps++
+			add(event, TimelineLap, lap.Time, "Lap %d: %s (%.3f m/s)", laps, FormatDuration(lap.Time), lap.Speed)
+		case domain.EventCannotContinue:
+			add(event, TimelineNotFinish, 0, "Cannot continue: %s", event.ExtraParams)
+		}
+	}
+
+	if competitor.Status == domain.StatusFinished {
+		finish := domain.NewEvent(competitor.FinishTime, domain.EventTypeOutgoing, int(domain.EventFinished), competitorID, "")
+		text := "Finished in %s"
+		args := []interface{}{FormatDuration(raceTime(competitor))}
+		for _, result := range s.GetResults() {
+			if result.Competitor == competitor && result.Rank > 0 {
+				text += ", rank %d"
+				args = append(args, result.Rank)
+			}
+		}
+		add(finish, TimelineFinish, 0, text, args...)
+	}
+	return timeline, nil
+}
+
+// String renders the timeline with the race time and the time since the start
+func (t *Timeline) String() string {
+	var b strings.Builder
+	label := fmt.Sprintf("competitor(%d)", t.Competitor.ID)
+	if name := t.Competitor.Name(); name != "" {
+		label = fmt.Sprintf("competitor(%d, %s)", t.Competitor.ID, name)
+	}
+	fmt.Fprintf(&b, "Timeline of the %s\n", label)
+
+	for _, entry := range t.Entries {
+		elapsed := strings.Repeat(" ", 13)
+		if entry.Started {
+			elapsed = "+" + FormatDuration(entry.Elapsed)
+		}
+		fmt.Fprintf(&b, "[%s] %s %s\n", entry.Time.Format("15:04:05.000"), elapsed, entry.Text)
+	}
+
+	if len(t.Warnings) > 0 {
+		b.WriteString("Timing warnings:\n")
+		for _, warning := range t.Warnings {
+			fmt.Fprintf(&b, "  %s\n", warning)
+		}
+	}
+	return b.String()
+}
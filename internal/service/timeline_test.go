@@ -0,0 +1,103 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTimeline(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	service.SetAthletes(domain.AthleteRegistry{1: {CompetitorID: 1, Name: "Ingrid Solberg"}})
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("09:59:30.000", domain.EventOnStartLine, 1, ""),
+		testutil.Incoming("10:00:02.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1"),
+		testutil.Incoming("10:05:10.000", domain.EventTargetHit, 1, "1"),
+		testutil.Incoming("10:05:12.000", domain.EventTargetHit, 1, "2"),
+		testutil.Incoming("10:05:14.000", domain.EventTargetHit, 1, "3"),
+		testutil.Incoming("10:05:16.000", domain.EventTargetHit, 1, "4"),
+		testutil.Incoming("10:05:30.000", domain.EventLeftFiringRange, 1, ""),
+		testutil.Incoming("10:05:40.000", domain.EventEnteredPenaltyLaps, 1, ""),
+		testutil.Incoming("10:06:40.000", domain.EventLeftPenaltyLaps, 1, ""),
+		testutil.Incoming("10:12:02.000", domain.EventEndedMainLap, 1, ""),
+	)
+
+	timeline, err := service.GetTimeline(1)
+	require.NoError(t, err)
+
+	kinds := make([]TimelineKind, 0, len(timeline.Entries))
+	for _, entry := range timeline.Entries {
+		kinds = append(kinds, entry.Kind)
+	}
+	assert.Equal(t, []TimelineKind{
+		TimelineRegistered, TimelineDraw, TimelineStartLine, TimelineStart,
+		TimelineRange, TimelinePenalty, TimelineLap, TimelineFinish,
+	}, kinds)
+
+	lap := timeline.Entries[6]
+	assert.Equal(t, 12*time.Minute, lap.Split)
+	assert.Equal(t, 12*time.Minute+2*time.Second, lap.Elapsed)
+
+	assert.Equal(t, `Timeline of the competitor(1, Ingrid Solberg)
+[09:00:00.000]               Registered
+[09:30:00.000]               Start time drawn: 10:00:00.000
+[09:59:30.000]               On the start line
+[10:00:02.000] +00:00:02.000 Started 00:00:02.000 after the planned start 10:00:00.000
+[10:05:00.000] +00:05:00.000 Firing range 1: 4/5 hits in 00:00:30.000
+[10:05:40.000] +00:05:40.000 Penalty loops: 1 x 150 m in 00:01:00.000
+[10:12:02.000] +00:12:02.000 Lap 1: 00:12:00.000 (4.167 m/s)
+[10:12:02.000] +00:12:02.000 Finished in 00:12:02.000, rank 1
+`, timeline.String())
+}
+
+func TestGetTimeline_NotFinished(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	processAll(t, service,
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1"),
+		testutil.Incoming("10:05:10.000", domain.EventTargetHit, 1, "1"),
+		testutil.Incoming("10:07:00.000", domain.EventCannotContinue, 1, "Broken ski"),
+	)
+
+	timeline, err := service.GetTimeline(1)
+	require.NoError(t, err)
+	require.Len(t, timeline.Entries, 5)
+	assert.Equal(t, "Started", timeline.Entries[2].Text)
+	assert.Equal(t, "Firing range 1: 1 hits so far", timeline.Entries[3].Text)
+	assert.Equal(t, TimelineNotFinish, timeline.Entries[4].Kind)
+	assert.Equal(t, "Cannot continue: Broken ski", timeline.Entries[4].Text)
+
+	_, err = service.GetTimeline(2)
+	assert.Error(t, err)
+}
+
+func TestTimeline_MarshalJSON(t *testing.T) {
+	service := NewCompetitionService(testutil.Config())
+	processAll(t, service, raceEvents(1, "10:00:00.000", "10:15:00.000")...)
+
+	timeline, err := service.GetTimeline(1)
+	require.NoError(t, err)
+	data, err := json.Marshal(timeline)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"competitorId": 1,
+		"entries": [
+			{"time": "09:00:00.000", "kind": "registered", "text": "Registered"},
+			{"time": "09:30:00.000", "kind": "draw", "text": "Start time drawn: 10:00:00.000"},
+			{"time": "10:00:00.000", "kind": "start", "text": "Started", "elapsed": "00:00:00.000"},
+			{"time": "10:15:00.000", "kind": "lap", "text": "Lap 1: 00:15:00.000 (3.333 m/s)", "split": "00:15:00.000", "elapsed": "00:15:00.000"},
+			{"time": "10:15:00.000", "kind": "finish", "text": "Finished in 00:15:00.000, rank 1", "elapsed": "00:15:00.000"}
+		]
+	}`, string(data))
+}
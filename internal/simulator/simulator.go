@@ -0,0 +1,225 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+var ErrInvalidOptions = errors.New("invalid simulation options")
+
+// Options describes the simulated field
+type Options struct {
+	Competitors    int
+	Seed           int64
+	Speed          float64 // mean skiing speed in m/s
+	SpeedStdDev    float64
+	Accuracy       float64 // mean probability of hitting a target
+	AccuracyStdDev float64
+	DNSRate        float64 // share of competitors who do not start
+	DNFRate        float64 // share of starters who do not finish
+	LateStartRate  float64 // share of starters who leave the start late
+}
+
+// DefaultOptions returns a field of club level athletes
+func DefaultOptions() Options {
+	return Options{
+		Competitors:    10,
+		Seed:           1,
+		Speed:          5.0,
+		SpeedStdDev:    0.4,
+		Accuracy:       0.8,
+		AccuracyStdDev: 0.1,
+		DNSRate:        0.05,
+		DNFRate:        0.05,
+		LateStartRate:  0.05,
+	}
+}
+
+func (o Options) Validate() error {
+	if o.Competitors <= 0 {
+		return fmt.Errorf("%w: number of competitors must be positive", ErrInvalidOptions)
+	}
+	if o.Speed <= 0 || o.SpeedStdDev < 0 {
+		return fmt.Errorf("%w: invalid speed distribution", ErrInvalidOptions)
+	}
+	if o.Accuracy < 0 || o.Accuracy > 1 || o.AccuracyStdDev < 0 {
+		return fmt.Errorf("%w: invalid accuracy distribution", ErrInvalidOptions)
+	}
+	for _, rate := range []float64{o.DNSRate, o.DNFRate, o.LateStartRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%w: rates must be between 0 and 1", ErrInvalidOptions)
+		}
+	}
+	return nil
+}
+
+const (
+	minSpeed        = 1.5 // m/s, nobody walks slower than this on a course
+	penaltySpeedPct = 0.9 // penalty loops are skied slightly slower than the course
+	shotInterval    = 3 * time.Second
+	rangeSetup      = 15 * time.Second
+)
+
+var dnfReasons = []string{
+	"Lost in the forest",
+	"Broken ski",
+	"Injury",
+	"Equipment failure",
+	"Exhausted",
+}
+
+// athlete holds the drawn abilities of one simulated competitor
+type athlete struct {
+	id       int
+	speed    float64
+	accuracy float64
+}
+
+// generator accumulates the events of one simulated race
+type generator struct {
+	config *domain.Config
+	opts   Options
+	rand   *rand.Rand
+	events []*domain.Event
+}
+
+// Generate simulates a full race and returns its incoming events in chronological order.
+// The same config, options and seed always produce the same events.
+func Generate(config *domain.Config, opts Options) ([]*domain.Event, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	start, err := config.GetStartTime()
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time format: %v", err)
+	}
+	delta, err := config.GetStartDelta()
+	if err != nil {
+		return nil, fmt.Errorf("invalid start delta format: %v", err)
+	}
+	interval := delta.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	g := &generator{
+		config: config,
+		opts:   opts,
+		rand:   rand.New(rand.NewSource(opts.Seed)),
+	}
+
+	// The start order is drawn independently of the competitor numbers
+	order := g.rand.Perm(opts.Competitors)
+	for i, position := range order {
+		a := athlete{
+			id:       i + 1,
+			speed:    math.Max(minSpeed, g.normal(opts.Speed, opts.SpeedStdDev)),
+			accuracy: math.Min(1, math.Max(0, g.normal(opts.Accuracy, opts.AccuracyStdDev))),
+		}
+		g.simulate(a, start.Add(time.Duration(position)*interval), start)
+	}
+
+	sort.SliceStable(g.events, func(i, j int) bool {
+		return g.events[i].Time.Before(g.events[j].Time)
+	})
+	return g.events, nil
+}
+
+func (g *generator) simulate(a athlete, plannedStart, raceStart time.Time) {
+	registered := raceStart.Add(-time.Duration(30+g.rand.Intn(30)) * time.Minute).Add(g.jitter(time.Minute))
+	g.emit(registered, domain.EventRegistered, a.id, "")
+	g.emit(plannedStart.Add(-5*time.Minute), domain.EventStartTimeSet, a.id, plannedStart.Format("15:04:05.000"))
+
+	if g.chance(g.opts.DNSRate) {
+		return
+	}
+	g.emit(plannedStart.Add(-time.Duration(15+g.rand.Intn(45))*time.Second), domain.EventOnStartLine, a.id, "")
+
+	started := plannedStart.Add(g.jitter(2 * time.Second))
+	if g.chance(g.opts.LateStartRate) {
+		started = plannedStart.Add(time.Duration(5+g.rand.Intn(25)) * time.Second).Add(g.jitter(time.Second))
+	}
+	g.emit(started, domain.EventStarted, a.id, "")
+
+	rules := g.config.RulesFor(g.config.CategoryOf(a.id))
+	dnfLap := -1
+	if g.chance(g.opts.DNFRate) {
+		dnfLap = g.rand.Intn(rules.Laps)
+	}
+
+	now := started
+	stage := 0
+	segments := g.config.FiringLines + 1
+	for lap := 0; lap < rules.Laps; lap++ {
+		// Each lap is split into equal course segments around its shooting stages
+		lapSpeed := a.speed * (1 + g.normal(0, 0.03))
+		segment := float64(rules.LapLen) / float64(segments)
+		for line := 0; line < g.config.FiringLines; line++ {
+			now = now.Add(skiTime(segment, lapSpeed))
+			stage++
+			now = g.shoot(a, stage, now, rules.PenaltyLen, lapSpeed)
+		}
+
+		if lap == dnfLap {
+			now = now.Add(time.Duration(g.rand.Float64() * float64(skiTime(segment, lapSpeed))))
+			g.emit(now, domain.EventCannotContinue, a.id, dnfReasons[g.rand.Intn(len(dnfReasons))])
+			return
+		}
+		now = now.Add(skiTime(segment, lapSpeed))
+		g.emit(now, domain.EventEndedMainLap, a.id, "")
+	}
+}
+
+// shoot simulates a visit to the firing range followed by the penalty loops for the misses
+func (g *generator) shoot(a athlete, stage int, now time.Time, penaltyLen int, speed float64) time.Time {
+	g.emit(now, domain.EventOnFiringRange, a.id, strconv.Itoa(stage))
+	now = now.Add(rangeSetup + g.jitter(3*time.Second))
+
+	misses := 0
+	for target := 1; target <= domain.TargetsPerRange; target++ {
+		now = now.Add(shotInterval + g.jitter(time.Second))
+		if g.chance(a.accuracy) {
+			g.emit(now, domain.EventTargetHit, a.id, strconv.Itoa(target))
+		} else {
+			misses++
+		}
+	}
+	now = now.Add(3*time.Second + g.jitter(time.Second))
+	g.emit(now, domain.EventLeftFiringRange, a.id, "")
+
+	if misses == 0 {
+		return now
+	}
+	now = now.Add(5*time.Second + g.jitter(2*time.Second))
+	g.emit(now, domain.EventEnteredPenaltyLaps, a.id, "")
+	now = now.Add(skiTime(float64(misses*penaltyLen), speed*penaltySpeedPct))
+	g.emit(now, domain.EventLeftPenaltyLaps, a.id, "")
+	return now
+}
+
+func (g *generator) emit(at time.Time, eventID domain.IncomingEventID, competitorID int, extra string) {
+	// Event files have millisecond resolution
+	at = at.Truncate(time.Millisecond)
+	g.events = append(g.events, domain.NewEvent(at, domain.EventTypeIncoming, int(eventID), competitorID, extra))
+}
+
+func (g *generator) normal(mean, stdDev float64) float64 {
+	return mean + g.rand.NormFloat64()*stdDev
+}
+
+func (g *generator) chance(p float64) bool {
+	return g.rand.Float64() < p
+}
+
+// jitter returns a random non-negative duration below max
+func (g *generator) jitter(max time.Duration) time.Duration {
+	return time.Duration(g.rand.Int63n(int64(max)))
+}
+
+func skiTime(distance, speed float64) time.Duration {
+	return time.Duration(distance / speed * float64(time.Second))
+}
@@ -0,0 +1,154 @@
+package simulator
+
+import (
+	"testing"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestConfig is a three lap race with two firing lines and a 30 second interval
+func newTestConfig() *domain.Config {
+	config := testutil.Config()
+	config.Laps = 3
+	config.FiringLines = 2
+	config.StartDelta = "00:00:30.000"
+	return config
+}
+
+func TestGenerate_Reproducible(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Seed = 42
+
+	first, err := Generate(newTestConfig(), opts)
+	require.NoError(t, err)
+	second, err := Generate(newTestConfig(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	opts.Seed = 43
+	third, err := Generate(newTestConfig(), opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, third)
+}
+
+func TestGenerate_RaceStructure(t *testing.T) {
+	config := newTestConfig()
+	opts := DefaultOptions()
+	opts.Competitors = 30
+	opts.DNSRate = 0
+	opts.DNFRate = 0
+	opts.LateStartRate = 0
+
+	events, err := Generate(config, opts)
+	require.NoError(t, err)
+
+	for i := 1; i < len(events); i++ {
+		assert.False(t, events[i].Time.Before(events[i-1].Time), "events must be chronological")
+	}
+
+	laps := make(map[int]int)
+	ranges := make(map[int]int)
+	hits := make(map[int]int)
+	penalties := make(map[int]int)
+	for _, event := range events {
+		switch domain.IncomingEventID(event.EventID) {
+		case domain.EventEndedMainLap:
+			laps[event.CompetitorID]++
+		case domain.EventOnFiringRange:
+			ranges[event.CompetitorID]++
+		case domain.EventTargetHit:
+			hits[event.CompetitorID]++
+		case domain.EventEnteredPenaltyLaps:
+			penalties[event.CompetitorID]++
+		}
+	}
+
+	for id := 1; id <= opts.Competitors; id++ {
+		assert.Equal(t, config.Laps, laps[id])
+		assert.Equal(t, config.Laps*config.FiringLines, ranges[id])
+		assert.LessOrEqual(t, hits[id], ranges[id]*domain.TargetsPerRange)
+		if hits[id] == ranges[id]*domain.TargetsPerRange {
+			assert.Zero(t, penalties[id])
+		} else {
+			assert.NotZero(t, penalties[id])
+		}
+	}
+
+	competition := service.NewCompetitionService(config)
+	for _, event := range events {
+		require.NoError(t, competition.ProcessEvent(event))
+	}
+	for _, result := range competition.GetResults() {
+		assert.Equal(t, domain.StatusFinished, result.Status)
+	}
+}
+
+func TestGenerate_Incidents(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Competitors = 50
+	opts.DNSRate = 0.2
+	opts.DNFRate = 0.2
+
+	events, err := Generate(newTestConfig(), opts)
+	require.NoError(t, err)
+
+	competition := service.NewCompetitionService(newTestConfig())
+	for _, event := range events {
+		require.NoError(t, competition.ProcessEvent(event))
+	}
+
+	statuses := make(map[domain.CompetitorStatus]int)
+	for _, result := range competition.GetResults() {
+		statuses[result.Status]++
+	}
+	assert.NotZero(t, statuses[domain.StatusNotStarted])
+	assert.NotZero(t, statuses[domain.StatusNotFinished])
+	assert.NotZero(t, statuses[domain.StatusFinished])
+}
+
+func TestGenerate_CategoryLaps(t *testing.T) {
+	config := newTestConfig()
+	config.Categories = []domain.CategoryConfig{{Name: "U17", Laps: 2, Competitors: []int{1}}}
+	opts := DefaultOptions()
+	opts.Competitors = 2
+	opts.DNSRate = 0
+	opts.DNFRate = 0
+
+	events, err := Generate(config, opts)
+	require.NoError(t, err)
+
+	laps := make(map[int]int)
+	for _, event := range events {
+		if event.EventID == int(domain.EventEndedMainLap) {
+			laps[event.CompetitorID]++
+		}
+	}
+	assert.Equal(t, 2, laps[1])
+	assert.Equal(t, 3, laps[2])
+}
+
+func TestOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+	}{
+		{"no competitors", func(o *Options) { o.Competitors = 0 }},
+		{"zero speed", func(o *Options) { o.Speed = 0 }},
+		{"accuracy above one", func(o *Options) { o.Accuracy = 1.5 }},
+		{"negative rate", func(o *Options) { o.DNFRate = -0.1 }},
+	}
+
+	assert.NoError(t, DefaultOptions().Validate())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			_, err := Generate(newTestConfig(), opts)
+			assert.ErrorIs(t, err, ErrInvalidOptions)
+		})
+	}
+}
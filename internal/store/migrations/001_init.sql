@@ -0,0 +1,47 @@
+-- Competitions with their config as JSON
+CREATE TABLE competitions (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT NOT NULL DEFAULT '',
+    date       TEXT NOT NULL DEFAULT '',
+    config     TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+
+-- Incoming events as received and the outgoing events they produced, in processing order
+CREATE TABLE events (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    competition_id INTEGER NOT NULL REFERENCES competitions(id) ON DELETE CASCADE,
+    direction      TEXT NOT NULL CHECK (direction IN ('incoming', 'outgoing')),
+    time           TEXT NOT NULL,
+    event_id       INTEGER NOT NULL,
+    competitor_id  INTEGER NOT NULL,
+    extra          TEXT NOT NULL DEFAULT '',
+    seq            INTEGER
+);
+
+CREATE INDEX events_competition ON events (competition_id, id);
+CREATE INDEX events_competitor ON events (competition_id, competitor_id);
+
+-- Results as computed after the last stored event; times in milliseconds
+CREATE TABLE results (
+    competition_id INTEGER NOT NULL REFERENCES competitions(id) ON DELETE CASCADE,
+    competitor_id  INTEGER NOT NULL,
+    athlete_id     TEXT NOT NULL DEFAULT '',
+    bib            INTEGER NOT NULL,
+    name           TEXT NOT NULL DEFAULT '',
+    category       TEXT NOT NULL DEFAULT '',
+    rank           INTEGER,
+    category_rank  INTEGER,
+    status         TEXT NOT NULL,
+    time_ms        INTEGER,
+    behind_ms      INTEGER,
+    ski_ms         INTEGER,
+    range_ms       INTEGER,
+    penalty_ms     INTEGER,
+    hits           INTEGER NOT NULL,
+    shots          INTEGER NOT NULL,
+    comment        TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (competition_id, competitor_id)
+);
+
+CREATE INDEX results_athlete ON results (athlete_id);
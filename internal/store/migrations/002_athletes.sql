@@ -0,0 +1,2 @@
+-- Athlete profiles of a competition as a JSON list, like the athletes file
+ALTER TABLE competitions ADD COLUMN athletes TEXT NOT NULL DEFAULT '[]';
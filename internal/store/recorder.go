@@ -0,0 +1,86 @@
+package store
+
+import (
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+)
+
+// Recorder is an event handler storing every event of a live competition as it is
+// applied. The results are stored again whenever a competitor finishes or drops out.
+// A late event applied in between the earlier ones, see ReorderBuffer, makes it store
+// all events and the results again so that they stay in the order they were applied.
+// Live inputs call SaveResults when they stop.
+type Recorder struct {
+	store         *Store
+	competitionID int64
+	// rewritten is set while the events of a late incoming event are handled; they
+	// were stored with it
+	rewritten bool
+	// OnError receives storage errors, which cannot stop the competition; they are
+	// dropped when it is nil
+	OnError func(err error)
+}
+
+// NewRecorder creates a competition from the config and athletes and returns a
+// recorder for it
+func (s *Store) NewRecorder(config *domain.Config, athletes domain.AthleteRegistry) (*Recorder, error) {
+	id, err := s.CreateCompetition(config, athletes)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{store: s, competitionID: id}, nil
+}
+
+// CompetitionID returns the ID of the recorded competition
+func (r *Recorder) CompetitionID() int64 {
+	return r.competitionID
+}
+
+func (r *Recorder) HandleBefore(*service.CompetitionService, *domain.Event) error {
+	return nil
+}
+
+func (r *Recorder) HandleAfter(s *service.CompetitionService, event *domain.Event) {
+	if event.Type == domain.EventTypeIncoming {
+		r.rewritten = !isLastIncoming(s.GetEvents(), event)
+	}
+	var err error
+	switch {
+	case event.Type == domain.EventTypeIncoming && r.rewritten:
+		err = r.store.ReplaceEvents(r.competitionID, s.GetEvents())
+	case !r.rewritten:
+		err = r.store.AddEvent(r.competitionID, event)
+	}
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	// A reprocessed competitor may change without producing a new outgoing event; its
+	// results are stored with the late event, which is handled after it was applied
+	if event.Type == domain.EventTypeIncoming && r.rewritten || event.Type == domain.EventTypeOutgoing && !r.rewritten {
+		if err := r.SaveResults(s); err != nil {
+			r.fail(err)
+		}
+	}
+}
+
+// SaveResults stores the current results of the competition
+func (r *Recorder) SaveResults(s *service.CompetitionService) error {
+	return r.store.SaveResults(r.competitionID, s)
+}
+
+func (r *Recorder) fail(err error) {
+	if r.OnError != nil {
+		r.OnError(err)
+	}
+}
+
+// isLastIncoming reports whether event is the latest incoming event applied
+func isLastIncoming(events []*domain.Event, event *domain.Event) bool {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type == domain.EventTypeIncoming {
+			return events[i] == event
+		}
+	}
+	return false
+}
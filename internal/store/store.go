@@ -0,0 +1,454 @@
+package store
+
+import (
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+
+	// Pure Go SQLite driver, registered as "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+var ErrUnknownCompetition = errors.New("unknown competition")
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Store keeps competitions, their events and results in a SQLite file
+type Store struct {
+	db *sql.DB
+}
+
+// Competition describes a stored competition
+type Competition struct {
+	ID      int64     `json:"id"`
+	Name    string    `json:"name,omitempty"`
+	Date    string    `json:"date,omitempty"`
+	Created time.Time `json:"created"`
+	Events  int       `json:"events"`
+}
+
+// Result is a stored result line; times are zero for competitors without a rank
+type Result struct {
+	CompetitorID int
+	AthleteID    string
+	Bib          int
+	Name         string
+	Category     string
+	Rank         int
+	CategoryRank int
+	Status       string
+	Time         time.Duration
+	Behind       time.Duration
+	SkiTime      time.Duration
+	RangeTime    time.Duration
+	PenaltyTime  time.Duration
+	Hits         int
+	Shots        int
+	Comment      string
+}
+
+// Open opens or creates the database file and brings its schema up to date
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; one connection also keeps the pragmas in effect
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA foreign_keys = ON; PRAGMA busy_timeout = 5000"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error configuring database: %v", err)
+	}
+
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// DB returns the underlying database for queries the store does not cover
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// migrate applies the migrations newer than the schema version of the database.
+// Migrations are the files in migrations/, applied in the order of their number
+// prefix, each in its own transaction.
+func (s *Store) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return fmt.Errorf("error creating migrations table: %v", err)
+	}
+
+	var current int
+	if err := s.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+		return fmt.Errorf("error reading schema version: %v", err)
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(m.sql); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error applying migration %s: %v", m.name, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)",
+			m.version, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error recording migration %s: %v", m.name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("error applying migration %s: %v", m.name, err)
+		}
+	}
+	return nil
+}
+
+// Version returns the schema version of the database
+func (s *Store) Version() (int, error) {
+	var version int
+	err := s.db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has no version prefix", entry.Name())
+		}
+		data, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: entry.Name(), sql: string(data)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// CreateCompetition stores a config and the athlete profiles, which may be nil, and
+// returns the ID of the new competition
+func (s *Store) CreateCompetition(config *domain.Config, athletes domain.AthleteRegistry) (int64, error) {
+	return createCompetition(s.db, config, athletes)
+}
+
+func createCompetition(db execer, config *domain.Config, athletes domain.AthleteRegistry) (int64, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return 0, err
+	}
+	// Stored as a list ordered by competitor ID, the format of the athletes file
+	list := make([]*domain.Athlete, 0, len(athletes))
+	for _, athlete := range athletes {
+		list = append(list, athlete)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CompetitorID < list[j].CompetitorID })
+	athletesData, err := json.Marshal(list)
+	if err != nil {
+		return 0, err
+	}
+	result, err := db.Exec("INSERT INTO competitions (name, date, config, athletes, created_at) VALUES (?, ?, ?, ?, ?)",
+		config.Name, config.Date, string(data), string(athletesData), time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, fmt.Errorf("error storing competition: %v", err)
+	}
+	return result.LastInsertId()
+}
+
+// AddEvent appends an incoming or outgoing event to a competition
+func (s *Store) AddEvent(competitionID int64, event *domain.Event) error {
+	return addEvent(s.db, competitionID, event)
+}
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// ReplaceEvents stores the events of a competition again, e.g. after a late event
+// was applied in between the stored ones
+func (s *Store) ReplaceEvents(competitionID int64, events []*domain.Event) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM events WHERE competition_id = ?", competitionID); err != nil {
+		return fmt.Errorf("error replacing events: %v", err)
+	}
+	for _, event := range events {
+		if err := addEvent(tx, competitionID, event); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func addEvent(db execer, competitionID int64, event *domain.Event) error {
+	direction := "incoming"
+	if event.Type == domain.EventTypeOutgoing {
+		direction = "outgoing"
+	}
+	var seq interface{}
+	if event.Seq > 0 {
+		seq = event.Seq
+	}
+	_, err := db.Exec(`INSERT INTO events (competition_id, direction, time, event_id, competitor_id, extra, seq)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		competitionID, direction, event.Time.Format("15:04:05.000"), event.EventID, event.CompetitorID, event.ExtraParams, seq)
+	if err != nil {
+		return fmt.Errorf("error storing event: %v", err)
+	}
+	return nil
+}
+
+// SaveResults replaces the stored results of a competition with its current results
+func (s *Store) SaveResults(competitionID int64, competition *service.CompetitionService) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := saveResults(tx, competitionID, competition); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func saveResults(tx *sql.Tx, competitionID int64, competition *service.CompetitionService) error {
+	if _, err := tx.Exec("DELETE FROM results WHERE competition_id = ?", competitionID); err != nil {
+		return fmt.Errorf("error replacing results: %v", err)
+	}
+
+	breakdown := make(map[int]*service.Result)
+	for _, result := range competition.GetResults() {
+		breakdown[result.Competitor.ID] = result
+	}
+	for _, result := range competition.ExportResults().Results {
+		var rank, categoryRank, timeMs, behindMs, skiMs, rangeMs, penaltyMs interface{}
+		if result.Rank > 0 {
+			full := breakdown[result.CompetitorID]
+			rank, categoryRank = result.Rank, result.CategoryRank
+			timeMs, behindMs = full.Time.Milliseconds(), full.Behind.Milliseconds()
+			skiMs, rangeMs, penaltyMs = full.SkiTime.Milliseconds(), full.RangeTime.Milliseconds(), full.PenaltyTime.Milliseconds()
+		}
+		_, err := tx.Exec(`INSERT INTO results (competition_id, competitor_id, athlete_id, bib, name, category,
+			rank, category_rank, status, time_ms, behind_ms, ski_ms, range_ms, penalty_ms, hits, shots, comment)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			competitionID, result.CompetitorID, result.AthleteID, result.Bib, result.Name, result.Category,
+			rank, categoryRank, result.Status, timeMs, behindMs, skiMs, rangeMs, penaltyMs,
+			result.Hits, result.Shots, result.Comment)
+		if err != nil {
+			return fmt.Errorf("error storing results: %v", err)
+		}
+	}
+	return nil
+}
+
+// SaveCompetition stores a processed competition at once: its config and athletes,
+// all events in the order they were applied and the results. It returns the ID of
+// the new competition.
+func (s *Store) SaveCompetition(competition *service.CompetitionService) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	id, err := createCompetition(tx, competition.Config(), competition.Athletes())
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range competition.GetEvents() {
+		if err := addEvent(tx, id, event); err != nil {
+			return 0, err
+		}
+	}
+	if err := saveResults(tx, id, competition); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// Competitions lists the stored competitions, the latest first
+func (s *Store) Competitions() ([]Competition, error) {
+	rows, err := s.db.Query(`SELECT c.id, c.name, c.date, c.created_at,
+		(SELECT COUNT(*) FROM events e WHERE e.competition_id = c.id AND e.direction = 'incoming')
+		FROM competitions c ORDER BY c.id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	competitions := make([]Competition, 0)
+	for rows.Next() {
+		var c Competition
+		var created string
+		if err := rows.Scan(&c.ID, &c.Name, &c.Date, &created, &c.Events); err != nil {
+			return nil, err
+		}
+		c.Created, _ = time.Parse(time.RFC3339, created)
+		competitions = append(competitions, c)
+	}
+	return competitions, rows.Err()
+}
+
+// LoadConfig returns the config of a stored competition
+func (s *Store) LoadConfig(competitionID int64) (*domain.Config, error) {
+	var data string
+	err := s.db.QueryRow("SELECT config FROM competitions WHERE id = ?", competitionID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCompetition, competitionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config domain.Config
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return nil, fmt.Errorf("error parsing stored config: %v", err)
+	}
+	return &config, nil
+}
+
+// LoadAthletes returns the athlete profiles of a stored competition
+func (s *Store) LoadAthletes(competitionID int64) (domain.AthleteRegistry, error) {
+	var data string
+	err := s.db.QueryRow("SELECT athletes FROM competitions WHERE id = ?", competitionID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownCompetition, competitionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var athletes []*domain.Athlete
+	if err := json.Unmarshal([]byte(data), &athletes); err != nil {
+		return nil, fmt.Errorf("error parsing stored athletes: %v", err)
+	}
+	return domain.NewAthleteRegistry(athletes)
+}
+
+// Results returns the stored results of a competition in the order they were ranked
+func (s *Store) Results(competitionID int64) ([]Result, error) {
+	if _, err := s.LoadConfig(competitionID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`SELECT competitor_id, athlete_id, bib, name, category,
+		COALESCE(rank, 0), COALESCE(category_rank, 0), status,
+		COALESCE(time_ms, 0), COALESCE(behind_ms, 0), COALESCE(ski_ms, 0), COALESCE(range_ms, 0), COALESCE(penalty_ms, 0),
+		hits, shots, comment
+		FROM results WHERE competition_id = ? ORDER BY rowid`, competitionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]Result, 0)
+	for rows.Next() {
+		var r Result
+		var timeMs, behindMs, skiMs, rangeMs, penaltyMs int64
+		if err := rows.Scan(&r.CompetitorID, &r.AthleteID, &r.Bib, &r.Name, &r.Category,
+			&r.Rank, &r.CategoryRank, &r.Status, &timeMs, &behindMs, &skiMs, &rangeMs, &penaltyMs,
+			&r.Hits, &r.Shots, &r.Comment); err != nil {
+			return nil, err
+		}
+		r.Time = time.Duration(timeMs) * time.Millisecond
+		r.Behind = time.Duration(behindMs) * time.Millisecond
+		r.SkiTime = time.Duration(skiMs) * time.Millisecond
+		r.RangeTime = time.Duration(rangeMs) * time.Millisecond
+		r.PenaltyTime = time.Duration(penaltyMs) * time.Millisecond
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// LoadEvents returns the incoming events of a stored competition in the order they
+// were applied
+func (s *Store) LoadEvents(competitionID int64) ([]*domain.Event, error) {
+	rows, err := s.db.Query(`SELECT time, event_id, competitor_id, extra, COALESCE(seq, 0) FROM events
+		WHERE competition_id = ? AND direction = 'incoming' ORDER BY id`, competitionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*domain.Event, 0)
+	for rows.Next() {
+		var clock, extra string
+		var eventID, competitorID, seq int
+		if err := rows.Scan(&clock, &eventID, &competitorID, &extra, &seq); err != nil {
+			return nil, err
+		}
+		eventTime, err := time.Parse("15:04:05.000", clock)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing stored event time: %v", err)
+		}
+		event := domain.NewEvent(eventTime, domain.EventTypeIncoming, eventID, competitorID, extra)
+		event.Seq = seq
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// Load rebuilds a stored competition with its athletes by processing its stored
+// events again
+func (s *Store) Load(competitionID int64) (*service.CompetitionService, error) {
+	config, err := s.LoadConfig(competitionID)
+	if err != nil {
+		return nil, err
+	}
+	athletes, err := s.LoadAthletes(competitionID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.LoadEvents(competitionID)
+	if err != nil {
+		return nil, err
+	}
+	competition := service.NewCompetitionService(config)
+	competition.SetAthletes(athletes)
+	for _, event := range events {
+		if err := competition.ProcessEvent(event); err != nil {
+			return nil, fmt.Errorf("error processing stored event: %v", err)
+		}
+	}
+	return competition, nil
+}
@@ -0,0 +1,243 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/service"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEvents is a one lap race: 1 finishes, 2 gives up and 3 never starts
+func testEvents() []*domain.Event {
+	return []*domain.Event{
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 2, ""),
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 3, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 2, "10:01:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:01:00.000", domain.EventStarted, 2, ""),
+		testutil.Incoming("10:04:00.000", domain.EventCannotContinue, 2, "Broken ski"),
+		testutil.Incoming("10:10:00.000", domain.EventEndedMainLap, 1, ""),
+	}
+}
+
+func openTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "results.db")
+	s, err := Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+func TestOpen_Migrations(t *testing.T) {
+	s, path := openTestStore(t)
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	version, err := s.Version()
+	require.NoError(t, err)
+	assert.Equal(t, migrations[len(migrations)-1].version, version)
+	require.NoError(t, s.Close())
+
+	// Opening again applies nothing twice
+	s, err = Open(path)
+	require.NoError(t, err)
+	defer s.Close()
+	var applied int
+	require.NoError(t, s.DB().QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&applied))
+	assert.Equal(t, len(migrations), applied)
+}
+
+func TestStore_SaveCompetition(t *testing.T) {
+	s, _ := openTestStore(t)
+	competition := service.NewCompetitionService(testutil.Config())
+	athletes, err := domain.NewAthleteRegistry([]*domain.Athlete{
+		{CompetitorID: 1, Name: "Ingrid Solberg", Gender: "W"},
+		{CompetitorID: 2, Name: "Jonas Keller", Gender: "M"},
+	})
+	require.NoError(t, err)
+	competition.SetAthletes(athletes)
+	for _, event := range testEvents() {
+		require.NoError(t, competition.ProcessEvent(event))
+	}
+
+	id, err := s.SaveCompetition(competition)
+	require.NoError(t, err)
+
+	competitions, err := s.Competitions()
+	require.NoError(t, err)
+	require.Len(t, competitions, 1)
+	assert.Equal(t, id, competitions[0].ID)
+	assert.Equal(t, "Club Sprint", competitions[0].Name)
+	assert.Equal(t, 9, competitions[0].Events)
+
+	var outgoing int
+	require.NoError(t, s.DB().QueryRow(
+		"SELECT COUNT(*) FROM events WHERE competition_id = ? AND direction = 'outgoing'", id).Scan(&outgoing))
+	assert.Equal(t, 2, outgoing, "finish of 1 and disqualification of 2")
+
+	results, err := s.Results(id)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, 1, results[0].Rank)
+	assert.Equal(t, 1, results[0].CompetitorID)
+	assert.Equal(t, 10*time.Minute, results[0].Time)
+	assert.Equal(t, "NotFinished", results[1].Status)
+	assert.Equal(t, "Broken ski", results[1].Comment)
+	assert.Equal(t, 0, results[2].Rank)
+
+	// The stored events and athletes rebuild the same competition
+	loaded, err := s.Load(id)
+	require.NoError(t, err)
+	assert.Equal(t, competition.ExportResults(), loaded.ExportResults())
+	assert.Equal(t, athletes, loaded.Athletes())
+	assert.Equal(t, "Ingrid Solberg", loaded.ExportResults().Results[0].Name)
+}
+
+func TestStore_LoadUnknown(t *testing.T) {
+	s, _ := openTestStore(t)
+	_, err := s.LoadConfig(42)
+	assert.ErrorIs(t, err, ErrUnknownCompetition)
+	_, err = s.Results(42)
+	assert.ErrorIs(t, err, ErrUnknownCompetition)
+}
+
+func TestRecorder(t *testing.T) {
+	s, _ := openTestStore(t)
+	config := testutil.Config()
+	recorder, err := s.NewRecorder(config, nil)
+	require.NoError(t, err)
+	recorder.OnError = func(err error) { t.Error(err) }
+
+	competition := service.NewCompetitionService(config)
+	competition.AddHandler(recorder)
+	events := testEvents()
+	for _, event := range events[:len(events)-1] {
+		require.NoError(t, competition.ProcessEvent(event))
+	}
+
+	// The results are stored once someone drops out
+	results, err := s.Results(recorder.CompetitionID())
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, 0, results[0].Rank)
+
+	require.NoError(t, competition.ProcessEvent(events[len(events)-1]))
+	results, err = s.Results(recorder.CompetitionID())
+	require.NoError(t, err)
+	assert.Equal(t, 1, results[0].Rank)
+
+	stored, err := s.LoadEvents(recorder.CompetitionID())
+	require.NoError(t, err)
+	require.Len(t, stored, len(events))
+	for i, event := range events {
+		assert.Equal(t, event.String(), stored[i].String())
+	}
+}
+
+func TestRecorder_LateEvent(t *testing.T) {
+	s, _ := openTestStore(t)
+	config := testutil.Config()
+	recorder, err := s.NewRecorder(config, nil)
+	require.NoError(t, err)
+	recorder.OnError = func(err error) { t.Error(err) }
+
+	competition := service.NewCompetitionService(config)
+	competition.AddHandler(recorder)
+	buffer := service.NewReorderBuffer(competition, 0)
+
+	// Competitor 2 giving up arrives after the finish of 1 and is applied before it
+	events := testEvents()
+	late := events[7]
+	arrived := append(append([]*domain.Event{}, events[:7]...), events[8], late)
+	for _, event := range arrived {
+		require.NoError(t, buffer.Add(event))
+	}
+
+	applied := make([]string, 0, len(events))
+	for _, event := range competition.GetEvents() {
+		if event.Type == domain.EventTypeIncoming {
+			applied = append(applied, event.String())
+		}
+	}
+	stored, err := s.LoadEvents(recorder.CompetitionID())
+	require.NoError(t, err)
+	storedLines := make([]string, 0, len(stored))
+	for _, event := range stored {
+		storedLines = append(storedLines, event.String())
+	}
+	assert.Equal(t, applied, storedLines)
+	assert.Equal(t, late.String(), storedLines[7])
+
+	var outgoing int
+	require.NoError(t, s.DB().QueryRow("SELECT COUNT(*) FROM events WHERE competition_id = ? AND direction = 'outgoing'",
+		recorder.CompetitionID()).Scan(&outgoing))
+	assert.Equal(t, 2, outgoing)
+
+	loaded, err := s.Load(recorder.CompetitionID())
+	require.NoError(t, err)
+	assert.Equal(t, competition.ExportResults(), loaded.ExportResults())
+}
+
+func TestRecorder_LateEventWithoutOutgoing(t *testing.T) {
+	s, _ := openTestStore(t)
+	config := testutil.Config()
+	recorder, err := s.NewRecorder(config, nil)
+	require.NoError(t, err)
+	recorder.OnError = func(err error) { t.Error(err) }
+
+	competition := service.NewCompetitionService(config)
+	competition.AddHandler(recorder)
+	buffer := service.NewReorderBuffer(competition, 0)
+	for _, event := range []*domain.Event{
+		testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, ""),
+		testutil.Incoming("09:30:00.000", domain.EventStartTimeSet, 1, "10:00:00.000"),
+		testutil.Incoming("10:00:00.000", domain.EventStarted, 1, ""),
+		testutil.Incoming("10:05:00.000", domain.EventOnFiringRange, 1, "1"),
+		testutil.Incoming("10:05:10.000", domain.EventTargetHit, 1, "1"),
+		testutil.Incoming("10:05:30.000", domain.EventLeftFiringRange, 1, ""),
+		testutil.Incoming("10:10:00.000", domain.EventEndedMainLap, 1, ""),
+	} {
+		require.NoError(t, buffer.Add(event))
+	}
+
+	// A hit arriving after the finish changes the result without a new outgoing event
+	require.NoError(t, buffer.Add(testutil.Incoming("10:05:15.000", domain.EventTargetHit, 1, "2")))
+	require.Equal(t, 2, competition.ExportResults().Results[0].Hits)
+
+	results, err := s.Results(recorder.CompetitionID())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, 2, results[0].Hits)
+}
+
+func TestRecorder_SaveResults(t *testing.T) {
+	s, _ := openTestStore(t)
+	config := testutil.Config()
+	recorder, err := s.NewRecorder(config, nil)
+	require.NoError(t, err)
+	recorder.OnError = func(err error) { t.Error(err) }
+
+	competition := service.NewCompetitionService(config)
+	competition.AddHandler(recorder)
+	require.NoError(t, competition.ProcessEvent(testutil.Incoming("09:00:00.000", domain.EventRegistered, 1, "")))
+
+	// Nobody finished yet, so only stopping the live input stores the results
+	results, err := s.Results(recorder.CompetitionID())
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	require.NoError(t, recorder.SaveResults(competition))
+	results, err = s.Results(recorder.CompetitionID())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "NotStarted", results[0].Status)
+}
@@ -0,0 +1,32 @@
+// Package testutil holds the fixtures shared by the tests of the other packages
+package testutil
+
+import (
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// Config returns the config of a one lap race with one firing line, the competitors
+// starting a minute apart from 10:00. Tests change the copy they need.
+func Config() *domain.Config {
+	return &domain.Config{
+		Name:        "Club Sprint",
+		Date:        "2024-01-13",
+		Laps:        1,
+		LapLen:      3000,
+		PenaltyLen:  150,
+		FiringLines: 1,
+		Start:       "10:00:00.000",
+		StartDelta:  "00:01:00.000",
+	}
+}
+
+// Incoming returns an incoming event at the given clock time (hh:mm:ss.sss)
+func Incoming(clock string, eventID domain.IncomingEventID, competitorID int, extra string) *domain.Event {
+	at, err := time.Parse("15:04:05.000", clock)
+	if err != nil {
+		panic(err)
+	}
+	return domain.NewEvent(at, domain.EventTypeIncoming, int(eventID), competitorID, extra)
+}
@@ -0,0 +1,372 @@
+package validate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+)
+
+// Category groups issues of the same kind in the report
+type Category string
+
+const (
+	CategoryParse                 Category = "parse"
+	CategoryOrder                 Category = "order"
+	CategoryUnknownCompetitor     Category = "unknown-competitor"
+	CategoryDuplicateRegistration Category = "duplicate-registration"
+	CategoryIllegalTransition     Category = "illegal-transition"
+	CategoryLapCount              Category = "lap-count"
+	CategoryFiringLine            Category = "firing-line"
+	CategorySpeed                 Category = "speed"
+	CategoryDuplicate             Category = "duplicate"
+)
+
+// categories lists the categories in report order together with their headings
+var categories = []struct {
+	category Category
+	title    string
+}{
+	{CategoryParse, "Parse errors"},
+	{CategoryOrder, "Event order"},
+	{CategoryUnknownCompetitor, "Unknown competitors"},
+	{CategoryDuplicateRegistration, "Duplicate registrations"},
+	{CategoryIllegalTransition, "Illegal state transitions"},
+	{CategoryLapCount, "Lap counts"},
+	{CategoryFiringLine, "Firing lines"},
+	{CategorySpeed, "Impossible speeds"},
+	{CategoryDuplicate, "Duplicate events"},
+}
+
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Issue is a problem found on a line of the events file
+type Issue struct {
+	Line     int
+	Category Category
+	Severity Severity
+	Message  string
+}
+
+func (i *Issue) String() string {
+	return fmt.Sprintf("line %d: %s: %s", i.Line, i.Severity, i.Message)
+}
+
+// Report is the outcome of checking an events file
+type Report struct {
+	Lines  int
+	Events int
+	Issues []*Issue
+}
+
+// Errors returns the number of issues with error severity
+func (r *Report) Errors() int {
+	count := 0
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			count++
+		}
+	}
+	return count
+}
+
+// Warnings returns the number of issues with warning severity
+func (r *Report) Warnings() int {
+	return len(r.Issues) - r.Errors()
+}
+
+// ByCategory returns the issues of one category in line order
+func (r *Report) ByCategory(category Category) []*Issue {
+	issues := make([]*Issue, 0)
+	for _, issue := range r.Issues {
+		if issue.Category == category {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// String renders a summary followed by the issues grouped by category
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Checked %d events in %d lines: ", r.Events, r.Lines)
+	if len(r.Issues) == 0 {
+		b.WriteString("no issues found\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%s, %s\n", plural(r.Errors(), "error"), plural(r.Warnings(), "warning"))
+
+	for _, c := range categories {
+		issues := r.ByCategory(c.category)
+		if len(issues) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s (%d)\n", c.title, len(issues))
+		for _, issue := range issues {
+			fmt.Fprintf(&b, "  %s\n", issue)
+		}
+	}
+	return b.String()
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// competitor tracks what the checker knows about one competitor
+type competitor struct {
+	state    domain.State
+	rules    domain.Rules
+	laps     int
+	lapStart time.Time
+	ranges   int // firing range visits in the current lap
+	hits     int // hits at the current or last firing range
+	misses   int
+	penalty  time.Time
+	done     bool // finished or unable to continue
+}
+
+// checker holds the state of a single Check run
+type checker struct {
+	config      *domain.Config
+	athletes    domain.AthleteRegistry
+	report      *Report
+	competitors map[int]*competitor
+	line        int
+	last        time.Time
+	seen        map[string]int     // line of every event identity
+	recent      map[string]*recent // latest event per event, competitor and parameters
+}
+
+type recent struct {
+	time time.Time
+	line int
+}
+
+// Check reads an events file and reports every problem it finds without producing
+// results. athletes may be nil; when given, competitors missing from it are reported.
+// The error is only set when the events cannot be read.
+func Check(config *domain.Config, athletes domain.AthleteRegistry, r io.Reader) (*Report, error) {
+	c := &checker{
+		config:      config,
+		athletes:    athletes,
+		report:      &Report{Issues: make([]*Issue, 0)},
+		competitors: make(map[int]*competitor),
+		seen:        make(map[string]int),
+		recent:      make(map[string]*recent),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		c.line++
+		c.report.Lines++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		event, err := domain.ParseEvent(line)
+		if err != nil {
+			c.issue(CategoryParse, SeverityError, "%v", err)
+			continue
+		}
+		c.report.Events++
+		c.check(event)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c.report, nil
+}
+
+func (c *checker) issue(category Category, severity Severity, format string, args ...interface{}) {
+	c.report.Issues = append(c.report.Issues, &Issue{
+		Line:     c.line,
+		Category: category,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *checker) check(event *domain.Event) {
+	if c.report.Events > 1 && event.Time.Before(c.last) {
+		c.issue(CategoryOrder, SeverityWarning, "event at %s is earlier than the previous event at %s",
+			event.Time.Format("15:04:05.000"), c.last.Format("15:04:05.000"))
+	} else {
+		c.last = event.Time
+	}
+
+	eventID := domain.IncomingEventID(event.EventID)
+	if eventID < domain.EventRegistered || eventID > domain.EventCannotContinue {
+		c.issue(CategoryParse, SeverityError, "unknown event ID %d", event.EventID)
+		return
+	}
+	if c.checkDuplicate(event) {
+		return
+	}
+
+	label := fmt.Sprintf("competitor(%d)", event.CompetitorID)
+	comp, exists := c.competitors[event.CompetitorID]
+	if eventID == domain.EventRegistered {
+		if exists {
+			c.issue(CategoryDuplicateRegistration, SeverityError, "%s is already registered", label)
+			return
+		}
+		if c.athletes != nil {
+			if _, ok := c.athletes[event.CompetitorID]; !ok {
+				c.issue(CategoryUnknownCompetitor, SeverityWarning, "%s is not in the athletes file", label)
+			}
+		}
+		c.competitors[event.CompetitorID] = &competitor{
+			state: domain.Registered,
+			rules: c.config.RulesFor(c.config.CategoryFor(event.CompetitorID, c.athletes[event.CompetitorID])),
+		}
+		return
+	}
+	if !exists {
+		c.issue(CategoryUnknownCompetitor, SeverityError, "event %d for %s who is not registered", event.EventID, label)
+		return
+	}
+
+	if comp.done && eventID == domain.EventEndedMainLap && comp.laps >= comp.rules.Laps {
+		comp.laps++
+		c.issue(CategoryLapCount, SeverityError, "%s ended lap %d of a %d lap race", label, comp.laps, comp.rules.Laps)
+		return
+	}
+	if comp.done {
+		c.issue(CategoryIllegalTransition, SeverityError, "event %d for %s who is no longer racing", event.EventID, label)
+		return
+	}
+	next, err := comp.state.Apply(eventID)
+	if err != nil {
+		c.issue(CategoryIllegalTransition, SeverityError, "%s: %v", label, err)
+		return
+	}
+	comp.state = next
+
+	switch eventID {
+	case domain.EventStartTimeSet:
+		if _, err := time.Parse("15:04:05.000", event.ExtraParams); err != nil {
+			c.issue(CategoryParse, SeverityError, "invalid start time %q for %s", event.ExtraParams, label)
+		}
+	case domain.EventStarted:
+		comp.lapStart = event.Time
+	case domain.EventOnFiringRange:
+		c.checkFiringRange(comp, label, event.ExtraParams)
+	case domain.EventTargetHit:
+		target, err := strconv.Atoi(event.ExtraParams)
+		if err != nil || target < 1 || target > domain.TargetsPerRange {
+			c.issue(CategoryFiringLine, SeverityError, "invalid target %q hit by %s", event.ExtraParams, label)
+			return
+		}
+		comp.hits++
+	case domain.EventLeftFiringRange:
+		comp.misses = domain.TargetsPerRange - comp.hits
+		if comp.misses < 0 {
+			comp.misses = 0
+		}
+	case domain.EventEnteredPenaltyLaps:
+		comp.penalty = event.Time
+	case domain.EventLeftPenaltyLaps:
+		if comp.misses > 0 {
+			c.checkSpeed(label, "penalty laps", comp.misses*comp.rules.PenaltyLen, event.Time.Sub(comp.penalty), c.config.Limits().Penalty)
+		}
+		comp.misses = 0
+	case domain.EventEndedMainLap:
+		comp.laps++
+		c.checkSpeed(label, fmt.Sprintf("lap %d", comp.laps), comp.rules.LapLen, event.Time.Sub(comp.lapStart), c.config.Limits().Lap)
+		comp.lapStart = event.Time
+		comp.ranges = 0
+		if comp.laps >= comp.rules.Laps {
+			comp.done = true
+		}
+	case domain.EventCannotContinue:
+		comp.done = true
+	}
+}
+
+// checkDuplicate reports an event repeating an earlier one and whether it is an exact
+// duplicate, which the competition does not apply
+func (c *checker) checkDuplicate(event *domain.Event) bool {
+	key := event.Key()
+	if line, ok := c.seen[key]; ok {
+		severity := SeverityWarning
+		if c.config.RejectDuplicates() {
+			severity = SeverityError
+		}
+		c.issue(CategoryDuplicate, severity, "%s repeats line %d", event, line)
+		return true
+	}
+	c.seen[key] = c.line
+
+	similar := fmt.Sprintf("%d %d %s", event.EventID, event.CompetitorID, event.ExtraParams)
+	if previous, ok := c.recent[similar]; ok {
+		delta := event.Time.Sub(previous.time)
+		if delta < 0 {
+			delta = -delta
+		}
+		if window := c.config.DuplicateWindow(); window > 0 && delta <= window {
+			c.issue(CategoryDuplicate, SeverityWarning, "%s repeats line %d after %s", event, previous.line, delta)
+		}
+	}
+	c.recent[similar] = &recent{time: event.Time, line: c.line}
+	return false
+}
+
+// checkFiringRange verifies the firing range number and the number of visits in the lap.
+// Range numbers count the shooting stages of the race, so they go up to laps × firing lines.
+func (c *checker) checkFiringRange(comp *competitor, label, extra string) {
+	comp.hits = 0
+	comp.ranges++
+	stages := comp.rules.Laps * c.config.FiringLines
+
+	number, err := strconv.Atoi(extra)
+	switch {
+	case err != nil:
+		c.issue(CategoryParse, SeverityError, "invalid firing range %q for %s", extra, label)
+	case number < 1 || number > stages:
+		c.issue(CategoryFiringLine, SeverityError, "%s is on firing range %d, the race has %d shooting stages (%d laps × %d firing lines)",
+			label, number, stages, comp.rules.Laps, c.config.FiringLines)
+	}
+	if comp.ranges > c.config.FiringLines {
+		c.issue(CategoryFiringLine, SeverityError, "%s visited the firing range %d times in lap %d, the race has %d firing lines",
+			label, comp.ranges, comp.laps+1, c.config.FiringLines)
+	}
+}
+
+func (c *checker) checkSpeed(label, segment string, distance int, d time.Duration, limits domain.SpeedRange) {
+	if d <= 0 {
+		c.issue(CategorySpeed, SeverityError, "%s: %s took %s", label, segment, d)
+		return
+	}
+	speed := float64(distance) / d.Seconds()
+	if limits.Contains(speed) {
+		return
+	}
+	// A slow segment can be real, a fast one is always a timing glitch
+	severity := SeverityError
+	if speed < limits.Min {
+		severity = SeverityWarning
+	}
+	c.issue(CategorySpeed, severity, "%s: %s at %.2f m/s is implausible, expected %s", label, segment, speed, limits)
+}
@@ -0,0 +1,283 @@
+package validate
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/numero_quadro/biathlon-tracker/internal/domain"
+	"github.com/numero_quadro/biathlon-tracker/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validRace is a clean two lap race of one competitor with two misses in the first stage
+const validRace = `[09:00:00.000] 1 1
+[09:10:00.000] 2 1 10:00:00.000
+[09:59:30.000] 3 1
+[10:00:01.000] 4 1
+[10:05:00.000] 5 1 1
+[10:05:10.000] 6 1 1
+[10:05:12.000] 6 1 2
+[10:05:14.000] 6 1 3
+[10:05:30.000] 7 1
+[10:05:40.000] 8 1
+[10:06:40.000] 9 1
+[10:10:00.000] 10 1
+[10:15:00.000] 5 1 2
+[10:15:30.000] 7 1
+[10:20:00.000] 10 1
+`
+
+func check(t *testing.T, events string) *Report {
+	t.Helper()
+	report, err := Check(newTestConfig(), nil, strings.NewReader(events))
+	require.NoError(t, err)
+	return report
+}
+
+// newTestConfig is a two lap race
+func newTestConfig() *domain.Config {
+	config := testutil.Config()
+	config.Laps = 2
+	return config
+}
+
+func TestCheck_Valid(t *testing.T) {
+	report := check(t, validRace)
+	assert.Empty(t, report.Issues)
+	assert.Equal(t, 15, report.Events)
+	assert.Equal(t, "Checked 15 events in 15 lines: no issues found\n", report.String())
+}
+
+func TestCheck_SampleRace(t *testing.T) {
+	data, err := os.ReadFile("../../sunny_5_skiers/config.json")
+	require.NoError(t, err)
+	config := &domain.Config{}
+	require.NoError(t, json.Unmarshal(data, config))
+
+	file, err := os.Open("../../sunny_5_skiers/events")
+	require.NoError(t, err)
+	defer file.Close()
+
+	report, err := Check(config, nil, file)
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues)
+}
+
+func TestCheck_Issues(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   string
+		category Category
+		severity Severity
+		line     int
+	}{
+		{
+			name:     "parse error",
+			events:   "[09:00:00.000] 1 1\nnot an event\n",
+			category: CategoryParse,
+			severity: SeverityError,
+			line:     2,
+		},
+		{
+			name:     "unknown event",
+			events:   "[09:00:00.000] 1 1\n[09:01:00.000] 42 1\n",
+			category: CategoryParse,
+			severity: SeverityError,
+			line:     2,
+		},
+		{
+			name:     "invalid start time",
+			events:   "[09:00:00.000] 1 1\n[09:01:00.000] 2 1 soon\n",
+			category: CategoryParse,
+			severity: SeverityError,
+			line:     2,
+		},
+		{
+			name:     "events out of order",
+			events:   "[09:00:00.000] 1 1\n[08:59:00.000] 1 2\n",
+			category: CategoryOrder,
+			severity: SeverityWarning,
+			line:     2,
+		},
+		{
+			name:     "unknown competitor",
+			events:   "[09:00:00.000] 1 1\n[09:01:00.000] 2 7 10:00:00.000\n",
+			category: CategoryUnknownCompetitor,
+			severity: SeverityError,
+			line:     2,
+		},
+		{
+			name:     "duplicate registration",
+			events:   "[09:00:00.000] 1 1\n[09:01:00.000] 1 1\n",
+			category: CategoryDuplicateRegistration,
+			severity: SeverityError,
+			line:     2,
+		},
+		{
+			name:     "exact duplicate",
+			events:   "[09:00:00.000] 1 1\n[09:00:00.000] 1 1\n",
+			category: CategoryDuplicate,
+			severity: SeverityWarning,
+			line:     2,
+		},
+		{
+			name:     "duplicate sequence number",
+			events:   "#1 [09:00:00.000] 1 1\n#1 [09:00:05.000] 1 2\n",
+			category: CategoryDuplicate,
+			severity: SeverityWarning,
+			line:     2,
+		},
+		{
+			name:     "near duplicate",
+			events:   "[09:00:00.000] 1 1\n[09:10:00.000] 2 1 10:00:00.000\n[10:00:00.000] 4 1\n[10:00:00.500] 4 1\n",
+			category: CategoryDuplicate,
+			severity: SeverityWarning,
+			line:     4,
+		},
+		{
+			name:     "started without a start time",
+			events:   "[09:00:00.000] 1 1\n[10:00:00.000] 4 1\n",
+			category: CategoryIllegalTransition,
+			severity: SeverityError,
+			line:     2,
+		},
+		{
+			name:     "event after the finish",
+			events:   validRace + "[10:21:00.000] 5 1 3\n",
+			category: CategoryIllegalTransition,
+			severity: SeverityError,
+			line:     16,
+		},
+		{
+			name: "too many laps",
+			events: "[09:00:00.000] 1 1\n[09:10:00.000] 2 1 10:00:00.000\n[10:00:00.000] 4 1\n" +
+				"[10:10:00.000] 10 1\n[10:20:00.000] 10 1\n[10:30:00.000] 10 1\n",
+			category: CategoryLapCount,
+			severity: SeverityError,
+			line:     6,
+		},
+		{
+			name: "firing range beyond the shooting stages",
+			events: "[09:00:00.000] 1 1\n[09:10:00.000] 2 1 10:00:00.000\n[10:00:00.000] 4 1\n" +
+				"[10:05:00.000] 5 1 3\n",
+			category: CategoryFiringLine,
+			severity: SeverityError,
+			line:     4,
+		},
+		{
+			name: "too many firing range visits in a lap",
+			events: "[09:00:00.000] 1 1\n[09:10:00.000] 2 1 10:00:00.000\n[10:00:00.000] 4 1\n" +
+				"[10:05:00.000] 5 1 1\n[10:05:30.000] 7 1\n[10:06:00.000] 5 1 2\n",
+			category: CategoryFiringLine,
+			severity: SeverityError,
+			line:     6,
+		},
+		{
+			name: "invalid target",
+			events: "[09:00:00.000] 1 1\n[09:10:00.000] 2 1 10:00:00.000\n[10:00:00.000] 4 1\n" +
+				"[10:05:00.000] 5 1 1\n[10:05:10.000] 6 1 6\n",
+			category: CategoryFiringLine,
+			severity: SeverityError,
+			line:     5,
+		},
+		{
+			name: "lap too fast",
+			events: "[09:00:00.000] 1 1\n[09:10:00.000] 2 1 10:00:00.000\n[10:00:00.000] 4 1\n" +
+				"[10:02:00.000] 10 1\n",
+			category: CategorySpeed,
+			severity: SeverityError,
+			line:     4,
+		},
+		{
+			name: "lap without duration",
+			events: "[09:00:00.000] 1 1\n[09:10:00.000] 2 1 10:00:00.000\n[10:00:00.000] 4 1\n" +
+				"[10:00:00.000] 10 1\n",
+			category: CategorySpeed,
+			severity: SeverityError,
+			line:     4,
+		},
+		{
+			name: "lap too slow",
+			events: "[09:00:00.000] 1 1\n[09:10:00.000] 2 1 10:00:00.000\n[10:00:00.000] 4 1\n" +
+				"[12:00:00.000] 10 1\n",
+			category: CategorySpeed,
+			severity: SeverityWarning,
+			line:     4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := check(t, tt.events)
+			issues := report.ByCategory(tt.category)
+			require.Len(t, issues, 1, report.String())
+			assert.Equal(t, tt.severity, issues[0].Severity)
+			assert.Equal(t, tt.line, issues[0].Line)
+		})
+	}
+}
+
+func TestCheck_LapCount(t *testing.T) {
+	config := newTestConfig()
+	config.Categories = []domain.CategoryConfig{{Name: "Youth", Laps: 1, Competitors: []int{1}}}
+	report, err := Check(config, nil, strings.NewReader(
+		"[09:00:00.000] 1 1\n[09:10:00.000] 2 1 10:00:00.000\n[10:00:00.000] 4 1\n[10:10:00.000] 10 1\n"))
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues, "the category rules allow a single lap")
+}
+
+func TestCheck_LapCountForGenderedAthlete(t *testing.T) {
+	config := newTestConfig()
+	config.Categories = []domain.CategoryConfig{{Name: "Youth", Laps: 1, Competitors: []int{1}}}
+	athletes := domain.AthleteRegistry{1: {CompetitorID: 1, Name: "Jonas Keller", Gender: "M"}}
+	report, err := Check(config, athletes, strings.NewReader(
+		"[09:00:00.000] 1 1\n[09:10:00.000] 2 1 10:00:00.000\n[10:00:00.000] 4 1\n[10:10:00.000] 10 1\n"))
+	require.NoError(t, err)
+	assert.Empty(t, report.Issues, "the config category wins over the gender")
+}
+
+func TestCheck_Athletes(t *testing.T) {
+	athletes := domain.AthleteRegistry{1: {CompetitorID: 1, Name: "Ingrid Solberg"}}
+	report, err := Check(newTestConfig(), athletes, strings.NewReader("[09:00:00.000] 1 1\n[09:00:01.000] 1 2\n"))
+	require.NoError(t, err)
+
+	issues := report.ByCategory(CategoryUnknownCompetitor)
+	require.Len(t, issues, 1)
+	assert.Equal(t, SeverityWarning, issues[0].Severity)
+	assert.Contains(t, issues[0].Message, "competitor(2)")
+}
+
+func TestCheck_RejectDuplicates(t *testing.T) {
+	config := newTestConfig()
+	config.Duplicates = &domain.DuplicateRules{Reject: true, Window: "00:00:00.000"}
+	report, err := Check(config, nil, strings.NewReader(
+		"[09:00:00.000] 1 1\n[09:00:00.000] 1 1\n[09:00:00.200] 1 1\n"))
+	require.NoError(t, err)
+
+	issues := report.ByCategory(CategoryDuplicate)
+	require.Len(t, issues, 1)
+	assert.Equal(t, SeverityError, issues[0].Severity)
+	assert.Equal(t, "[09:00:00.000] 1 1 repeats line 1", issues[0].Message)
+	// Without a window the near duplicate is only a second registration
+	assert.Len(t, report.ByCategory(CategoryDuplicateRegistration), 1)
+}
+
+func TestReport_String(t *testing.T) {
+	report := check(t, "[09:00:00.000] 1 1\ngarbage\n[08:00:00.000] 1 1\n")
+	assert.Equal(t, 2, report.Errors())
+	assert.Equal(t, 1, report.Warnings())
+	assert.Equal(t, `Checked 2 events in 3 lines: 2 errors, 1 warning
+
+Parse errors (1)
+  line 2: error: invalid event format: "garbage"
+
+Event order (1)
+  line 3: warning: event at 08:00:00.000 is earlier than the previous event at 09:00:00.000
+
+Duplicate registrations (1)
+  line 3: error: competitor(1) is already registered
+`, report.String())
+}